@@ -1,14 +1,18 @@
 package drpv4
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"gitlab.com/rackn/provision/v4/models"
 	"gitlab.com/rackn/provision/v4/test"
 )
 
@@ -48,3 +52,39 @@ func accRandomSuffix(n int) string {
 	}
 	return string(b)
 }
+
+// testAccClient connects to the acceptance test server with the RS_* credentials, for
+// setting up and checking objects outside of Terraform.
+func testAccClient(t *testing.T) *Config {
+	t.Helper()
+	c := &Config{token: os.Getenv("RS_TOKEN"), endpoint: os.Getenv("RS_ENDPOINT"), retry: defaultRetryPolicy()}
+	if key := os.Getenv("RS_KEY"); key != "" {
+		c.username, c.password, _ = strings.Cut(key, ":")
+	}
+	if err := c.validateAndConnect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// testAccMachines creates n machines named prefix-0 to prefix-(n-1) and deletes them when
+// the test ends.
+func testAccMachines(t *testing.T, c *Config, prefix string, n int) []*models.Machine {
+	t.Helper()
+	ctx := context.Background()
+	out := make([]*models.Machine, 0, n)
+	for i := range n {
+		m := &models.Machine{}
+		m.Name = fmt.Sprintf("%s-%d", prefix, i)
+		if err := c.createModel(ctx, m); err != nil {
+			t.Fatalf("create machine %s: %s", m.Name, err)
+		}
+		t.Cleanup(func() {
+			if _, err := c.deleteModel(ctx, "machines", m.UUID()); err != nil && !isNotFound(err) {
+				t.Errorf("delete machine %s: %s", m.Name, err)
+			}
+		})
+		out = append(out, m)
+	}
+	return out
+}
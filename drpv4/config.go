@@ -6,7 +6,13 @@ package drpv4
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"gitlab.com/rackn/provision/v4/api"
@@ -18,20 +24,135 @@ type Config struct {
 	password string
 	endpoint string
 
+	caCertPEM    string
+	caCertFile   string
+	clientCert   string
+	clientKey    string
+	serverVerify bool
+
+	retry  retryPolicy
+	params paramCache
+
+	validateReferences bool
+
 	session *api.Client
 }
 
+// caPEM returns the configured CA bundle, reading ca_cert_file when no inline PEM is set.
+func (c *Config) caPEM() ([]byte, error) {
+	if c.caCertPEM != "" {
+		return []byte(c.caCertPEM), nil
+	}
+	if c.caCertFile == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(c.caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CA bundle %s: %w", c.caCertFile, err)
+	}
+	return b, nil
+}
+
+// tlsConfig builds the TLS settings used to verify the DRP server, failing early on a CA
+// bundle without certificates or a client key pair that does not load.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: !c.serverVerify}
+	ca, err := c.caPEM()
+	if err != nil {
+		return nil, err
+	}
+	if ca != nil {
+		pool, _ := x509.SystemCertPool()
+		if pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("CA bundle does not contain any PEM encoded certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	if c.clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.clientCert, c.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// verifyServerCertificate performs a TLS handshake against the endpoint so an untrusted
+// certificate chain is reported directly instead of as a failed API call.
+func (c *Config) verifyServerCertificate(cfg *tls.Config) error {
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %s: %w", c.endpoint, err)
+	}
+	if u.Scheme != "https" || !c.serverVerify {
+		return nil
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", host, cfg)
+	if err != nil {
+		return fmt.Errorf("TLS verification of %s failed: %w", c.endpoint, err)
+	}
+	return conn.Close()
+}
+
+// caCertPath returns a CA bundle path for the API client, which only reads CA certs from
+// disk. Inline PEM is written to a temporary file that the returned cleanup removes.
+func (c *Config) caCertPath() (string, func(), error) {
+	if c.caCertPEM == "" {
+		return c.caCertFile, func() {}, nil
+	}
+	f, err := os.CreateTemp("", "drp-ca-*.pem")
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	if _, err := f.WriteString(c.caCertPEM); err != nil {
+		f.Close()
+		cleanup()
+		return "", func() {}, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return f.Name(), cleanup, nil
+}
+
 func (c *Config) validateAndConnect(ctx context.Context) error {
 	tflog.Debug(ctx, "Configuring the DRP API client")
 
 	if c.session != nil {
 		return nil
 	}
-	var err error
-	if c.token != "" {
-		c.session, err = api.TokenSession(c.endpoint, c.token)
-	} else {
-		c.session, err = api.UserSession(c.endpoint, c.username, c.password)
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if err := c.verifyServerCertificate(tlsCfg); err != nil {
+		return err
+	}
+	caFile, cleanup, err := c.caCertPath()
+	if err != nil {
+		return fmt.Errorf("unable to stage CA bundle: %w", err)
+	}
+	// The API client loads the CA bundle while building its transport, so the file is
+	// no longer needed once the session exists.
+	defer cleanup()
+
+	switch {
+	case c.clientCert != "":
+		c.session, err = api.ClientCertSession(context.Background(), c.endpoint, c.clientCert, c.clientKey, true, c.serverVerify, caFile)
+	case c.token != "":
+		c.session, err = api.TokenSessionProxyServer(c.endpoint, c.token, true, c.serverVerify, caFile)
+	default:
+		c.session, err = api.UserSessionTokenProxyContextServer(context.Background(), c.endpoint, c.username, c.password, true, true, c.serverVerify, caFile)
 	}
 	if err != nil {
 		tflog.Error(ctx, "Error creating session", map[string]interface{}{"error": err.Error()})
@@ -0,0 +1,170 @@
+package drpv4
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertPEM returns a self-signed certificate and its key, both PEM encoded.
+func testCertPEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "drp-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func writeTestFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestConfigTLSConfig(t *testing.T) {
+	certPEM, keyPEM := testCertPEM(t)
+	otherCertPEM, _ := testCertPEM(t)
+
+	cases := []struct {
+		name    string
+		cfg     *Config
+		wantErr string
+	}{
+		{
+			name: "no tls settings",
+			cfg:  &Config{serverVerify: true},
+		},
+		{
+			name: "inline CA bundle",
+			cfg:  &Config{serverVerify: true, caCertPEM: certPEM},
+		},
+		{
+			name: "CA bundle file",
+			cfg:  &Config{serverVerify: true, caCertFile: writeTestFile(t, "ca.pem", certPEM)},
+		},
+		{
+			name:    "CA bundle without certificates",
+			cfg:     &Config{serverVerify: true, caCertPEM: "not a certificate"},
+			wantErr: "does not contain any PEM encoded certificates",
+		},
+		{
+			name:    "missing CA bundle file",
+			cfg:     &Config{serverVerify: true, caCertFile: filepath.Join(t.TempDir(), "missing.pem")},
+			wantErr: "unable to read CA bundle",
+		},
+		{
+			name: "client key pair",
+			cfg: &Config{
+				clientCert: writeTestFile(t, "client.pem", certPEM),
+				clientKey:  writeTestFile(t, "client-key.pem", keyPEM),
+			},
+		},
+		{
+			name: "client key pair that does not match",
+			cfg: &Config{
+				clientCert: writeTestFile(t, "client.pem", otherCertPEM),
+				clientKey:  writeTestFile(t, "client-key.pem", keyPEM),
+			},
+			wantErr: "unable to load client certificate",
+		},
+		{
+			name: "client key that is not a key",
+			cfg: &Config{
+				clientCert: writeTestFile(t, "client.pem", certPEM),
+				clientKey:  writeTestFile(t, "client-key.pem", "garbage"),
+			},
+			wantErr: "unable to load client certificate",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.cfg.tlsConfig()
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("tlsConfig() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tlsConfig() error = %v", err)
+			}
+			if got.InsecureSkipVerify != !tc.cfg.serverVerify {
+				t.Errorf("InsecureSkipVerify = %v, want %v", got.InsecureSkipVerify, !tc.cfg.serverVerify)
+			}
+			if (tc.cfg.caCertPEM != "" || tc.cfg.caCertFile != "") && got.RootCAs == nil {
+				t.Error("RootCAs not set for a CA bundle")
+			}
+			if tc.cfg.clientCert != "" && len(got.Certificates) != 1 {
+				t.Errorf("got %d client certificates, want 1", len(got.Certificates))
+			}
+		})
+	}
+}
+
+func TestConfigCACertPath(t *testing.T) {
+	certPEM, _ := testCertPEM(t)
+
+	c := Config{caCertPEM: certPEM}
+	p, cleanup, err := c.caCertPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != certPEM {
+		t.Error("temporary CA bundle does not hold the inline PEM")
+	}
+	cleanup()
+	if _, err := os.Stat(p); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("temporary CA bundle %s still exists after cleanup: %v", p, err)
+	}
+
+	file := writeTestFile(t, "ca.pem", certPEM)
+	c = Config{caCertFile: file}
+	p, cleanup, err = c.caCertPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != file {
+		t.Errorf("caCertPath() = %s, want %s", p, file)
+	}
+	cleanup()
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("cleanup removed the configured ca_cert_file: %v", err)
+	}
+}
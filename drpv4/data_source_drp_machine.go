@@ -0,0 +1,351 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var (
+	_ datasource.DataSource                     = (*machineDataSource)(nil)
+	_ datasource.DataSourceWithConfigure        = (*machineDataSource)(nil)
+	_ datasource.DataSourceWithConfigValidators = (*machineDataSource)(nil)
+)
+
+type machineDataSource struct {
+	client *Config
+}
+
+func NewMachineDataSource() datasource.DataSource {
+	return &machineDataSource{}
+}
+
+func (d *machineDataSource) Metadata(_ context.Context, _ datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = "drp_machine"
+}
+
+func (d *machineDataSource) ConfigValidators(_ context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.AtLeastOneOf(
+			path.MatchRoot("id"),
+			path.MatchRoot("name"),
+			path.MatchRoot("filters"),
+		),
+	}
+}
+
+// machineDetailAttributes returns the read-only machine attributes shared by the
+// machine data sources. id and name are added by the caller since their modes differ.
+func machineDetailAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"address": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Address.",
+			MarkdownDescription: "Digital Rebar Machine.Address.",
+		},
+		"pool": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Pool.",
+			MarkdownDescription: "Digital Rebar Machine.Pool.",
+		},
+		"pool_status": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.PoolStatus.",
+			MarkdownDescription: "Digital Rebar Machine.PoolStatus.",
+		},
+		"workflow": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Workflow.",
+			MarkdownDescription: "Digital Rebar Machine.Workflow.",
+		},
+		"stage": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Stage.",
+			MarkdownDescription: "Digital Rebar Machine.Stage.",
+		},
+		"bootenv": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.BootEnv.",
+			MarkdownDescription: "Digital Rebar Machine.BootEnv.",
+		},
+		"current_task": schema.Int64Attribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.CurrentTask.",
+			MarkdownDescription: "Digital Rebar Machine.CurrentTask.",
+		},
+		"runnable": schema.BoolAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Runnable.",
+			MarkdownDescription: "Digital Rebar Machine.Runnable.",
+		},
+		"workflow_complete": schema.BoolAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.WorkflowComplete.",
+			MarkdownDescription: "Digital Rebar Machine.WorkflowComplete.",
+		},
+		"arch": schema.StringAttribute{
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Arch.",
+			MarkdownDescription: "Digital Rebar Machine.Arch.",
+		},
+		"hardware_addrs": schema.ListAttribute{
+			ElementType:         types.StringType,
+			Computed:            true,
+			Description:         "Digital Rebar Machine.HardwareAddrs.",
+			MarkdownDescription: "Digital Rebar Machine.HardwareAddrs.",
+		},
+		"ip_addresses": schema.ListAttribute{
+			ElementType:         types.StringType,
+			Computed:            true,
+			Description:         "IP addresses found in the machine's net/* params.",
+			MarkdownDescription: "IP addresses found in the machine's `net/*` params.",
+		},
+		"profiles": schema.ListAttribute{
+			ElementType:         types.StringType,
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Profiles.",
+			MarkdownDescription: "Digital Rebar Machine.Profiles.",
+		},
+		"params": schema.MapAttribute{
+			ElementType:         types.StringType,
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Params (non-string values are JSON encoded).",
+			MarkdownDescription: "Digital Rebar Machine.Params (non-string values are JSON encoded).",
+		},
+		"meta": schema.MapAttribute{
+			ElementType:         types.StringType,
+			Computed:            true,
+			Description:         "Digital Rebar Machine.Meta.",
+			MarkdownDescription: "Digital Rebar Machine.Meta.",
+		},
+	}
+}
+
+func (d *machineDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attrs := machineDetailAttributes()
+	attrs["id"] = schema.StringAttribute{
+		Optional:            true,
+		Computed:            true,
+		Description:         "Machine UUID to look up.",
+		MarkdownDescription: "Machine UUID to look up.",
+	}
+	attrs["name"] = schema.StringAttribute{
+		Optional:            true,
+		Computed:            true,
+		Description:         "Machine name to look up.",
+		MarkdownDescription: "Machine name to look up.",
+	}
+	attrs["filters"] = schema.ListAttribute{
+		ElementType:         types.StringType,
+		Optional:            true,
+		Description:         "Selection filters (Digital Rebar format e.g. FilterVar=value).",
+		MarkdownDescription: "Selection filters (Digital Rebar format e.g. FilterVar=value).",
+	}
+	resp.Schema = schema.Schema{
+		Description:         "Looks up a single existing machine by UUID, name or filters.",
+		MarkdownDescription: "Looks up a single existing machine by UUID, name or filters.",
+		Attributes:          attrs,
+	}
+}
+
+func (d *machineDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.client = configureDataSourceClient(req, resp)
+}
+
+type machineDetailModel struct {
+	ID               types.String `tfsdk:"id"`
+	Name             types.String `tfsdk:"name"`
+	Address          types.String `tfsdk:"address"`
+	Pool             types.String `tfsdk:"pool"`
+	PoolStatus       types.String `tfsdk:"pool_status"`
+	Workflow         types.String `tfsdk:"workflow"`
+	Stage            types.String `tfsdk:"stage"`
+	BootEnv          types.String `tfsdk:"bootenv"`
+	CurrentTask      types.Int64  `tfsdk:"current_task"`
+	Runnable         types.Bool   `tfsdk:"runnable"`
+	WorkflowComplete types.Bool   `tfsdk:"workflow_complete"`
+	Arch             types.String `tfsdk:"arch"`
+	HardwareAddrs    types.List   `tfsdk:"hardware_addrs"`
+	IPAddresses      types.List   `tfsdk:"ip_addresses"`
+	Profiles         types.List   `tfsdk:"profiles"`
+	Params           types.Map    `tfsdk:"params"`
+	Meta             types.Map    `tfsdk:"meta"`
+}
+
+type machineDataSourceModel struct {
+	machineDetailModel
+	Filters types.List `tfsdk:"filters"`
+}
+
+// machineFilterParams converts FilterVar=value strings into the query
+// parameter pairs accepted by ListModel.
+func machineFilterParams(filters []string) ([]string, error) {
+	params := make([]string, 0, len(filters)*2)
+	for _, f := range filters {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) < 2 || kv[0] == "" {
+			return nil, fmt.Errorf("expected FilterVar=value, got %q", f)
+		}
+		params = append(params, kv[0], kv[1])
+	}
+	return params, nil
+}
+
+func flattenMachineParams(ctx context.Context, params map[string]interface{}, diags *diag.Diagnostics) types.Map {
+	sm := make(map[string]string, len(params))
+	for k, v := range params {
+		s, err := convertParamToString(v)
+		if err != nil {
+			diags.AddError("Flatten machine params", fmt.Sprintf("param %s: %s", k, err))
+			return types.MapNull(types.StringType)
+		}
+		sm[k] = s
+	}
+	mv, d := types.MapValueFrom(ctx, types.StringType, sm)
+	diags.Append(d...)
+	return mv
+}
+
+// machineIPAddresses collects the IP addresses, with any prefix length dropped, found
+// anywhere in the values of the machine's net/* params.
+func machineIPAddresses(params map[string]interface{}) []string {
+	var walk func(v interface{})
+	seen := map[string]bool{}
+	out := []string{}
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case string:
+			addr, err := netip.ParseAddr(t)
+			if err != nil {
+				p, perr := netip.ParsePrefix(t)
+				if perr != nil {
+					return
+				}
+				addr = p.Addr()
+			}
+			if s := addr.String(); !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]interface{}:
+			for _, k := range slices.Sorted(maps.Keys(t)) {
+				walk(t[k])
+			}
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(params)) {
+		if strings.HasPrefix(k, "net/") {
+			walk(params[k])
+		}
+	}
+	return out
+}
+
+func flattenMachineDetail(ctx context.Context, mo *models.Machine, m *machineDetailModel, diags *diag.Diagnostics) {
+	m.ID = types.StringValue(mo.Uuid.String())
+	m.Name = types.StringValue(mo.Name)
+	m.Address = types.StringValue(mo.Address.String())
+	m.Pool = types.StringValue(mo.Pool)
+	m.PoolStatus = types.StringValue(string(mo.PoolStatus))
+	m.Workflow = types.StringValue(mo.Workflow)
+	m.Stage = types.StringValue(mo.Stage)
+	m.BootEnv = types.StringValue(mo.BootEnv)
+	m.CurrentTask = types.Int64Value(int64(mo.CurrentTask))
+	m.Runnable = types.BoolValue(mo.Runnable)
+	m.WorkflowComplete = types.BoolValue(mo.WorkflowComplete)
+	m.Arch = types.StringValue(mo.Arch)
+	hwAddrs := mo.HardwareAddrs
+	if hwAddrs == nil {
+		hwAddrs = []string{}
+	}
+	hv, d := types.ListValueFrom(ctx, types.StringType, hwAddrs)
+	diags.Append(d...)
+	m.HardwareAddrs = hv
+	iv, d := types.ListValueFrom(ctx, types.StringType, machineIPAddresses(mo.Params))
+	diags.Append(d...)
+	m.IPAddresses = iv
+	profiles := mo.Profiles
+	if profiles == nil {
+		profiles = []string{}
+	}
+	lv, d := types.ListValueFrom(ctx, types.StringType, profiles)
+	diags.Append(d...)
+	m.Profiles = lv
+	m.Params = flattenMachineParams(ctx, mo.Params, diags)
+	meta := map[string]string(mo.Meta)
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	mv, d := types.MapValueFrom(ctx, types.StringType, meta)
+	diags.Append(d...)
+	m.Meta = mv
+}
+
+func (d *machineDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "Expected a configured provider before this data source is used.")
+		return
+	}
+	var data machineDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filters, diags := diagListToStringSlice(ctx, data.Filters)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	params, err := machineFilterParams(filters)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("filters"), "Invalid filters entry", err.Error())
+		return
+	}
+	if id := data.ID.ValueString(); id != "" {
+		params = append(params, "Uuid", id)
+	}
+	if name := data.Name.ValueString(); name != "" {
+		params = append(params, "Name", name)
+	}
+
+	mo, err := d.client.listModel(ctx, "machines", params...)
+	if err != nil {
+		resp.Diagnostics.AddError("Machine lookup failed", err.Error())
+		return
+	}
+	switch len(mo) {
+	case 0:
+		resp.Diagnostics.AddError("Machine not found", fmt.Sprintf("No machine matched the query %v.", params))
+		return
+	case 1:
+	default:
+		names := make([]string, 0, len(mo))
+		for _, m := range mo {
+			names = append(names, m.(*models.Machine).Name)
+		}
+		resp.Diagnostics.AddError(
+			"Multiple machines found",
+			fmt.Sprintf("%d machines matched the query %v (%s); refine id, name or filters.", len(mo), params, strings.Join(names, ", ")),
+		)
+		return
+	}
+
+	flattenMachineDetail(ctx, mo[0].(*models.Machine), &data.machineDetailModel, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,34 @@
+package drpv4
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccMachineDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					data "drp_machine" "test" {
+						name = "tfmachine-%s"
+					}
+				`, accRandomSuffix(10)),
+				ExpectError: regexp.MustCompile("Machine not found"),
+			},
+			{
+				Config: `
+					data "drp_machine" "test" {
+						filters = ["Address"]
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid filters entry"),
+			},
+		},
+	})
+}
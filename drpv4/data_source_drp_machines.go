@@ -0,0 +1,154 @@
+package drpv4
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var (
+	_ datasource.DataSource              = (*machinesDataSource)(nil)
+	_ datasource.DataSourceWithConfigure = (*machinesDataSource)(nil)
+)
+
+type machinesDataSource struct {
+	client *Config
+}
+
+func NewMachinesDataSource() datasource.DataSource {
+	return &machinesDataSource{}
+}
+
+func (d *machinesDataSource) Metadata(_ context.Context, _ datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = "drp_machines"
+}
+
+func (d *machinesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	machineAttrs := machineDetailAttributes()
+	machineAttrs["id"] = schema.StringAttribute{
+		Computed:            true,
+		Description:         "Digital Rebar Machine.Uuid.",
+		MarkdownDescription: "Digital Rebar Machine.Uuid.",
+	}
+	machineAttrs["name"] = schema.StringAttribute{
+		Computed:            true,
+		Description:         "Digital Rebar Machine.Name.",
+		MarkdownDescription: "Digital Rebar Machine.Name.",
+	}
+	resp.Schema = schema.Schema{
+		Description:         "Lists machines matching server-side filters.",
+		MarkdownDescription: "Lists machines matching server-side filters.",
+		Attributes: map[string]schema.Attribute{
+			"filters": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Selection filters (Digital Rebar format e.g. Pool=Eq(k8s-workers) or Runnable=true).",
+				MarkdownDescription: "Selection filters (Digital Rebar format e.g. `Pool=Eq(k8s-workers)` or `Runnable=true`).",
+			},
+			"sort": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Index to sort the results by.",
+				MarkdownDescription: "Index to sort the results by.",
+			},
+			"reverse": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Reverse the sort order.",
+				MarkdownDescription: "Reverse the sort order.",
+			},
+			"limit": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Maximum number of machines to return.",
+				MarkdownDescription: "Maximum number of machines to return.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"offset": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Number of machines to skip before returning results.",
+				MarkdownDescription: "Number of machines to skip before returning results.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"machines": schema.ListNestedAttribute{
+				Computed:            true,
+				Description:         "Machines matching the query.",
+				MarkdownDescription: "Machines matching the query.",
+				NestedObject:        schema.NestedAttributeObject{Attributes: machineAttrs},
+			},
+		},
+	}
+}
+
+func (d *machinesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.client = configureDataSourceClient(req, resp)
+}
+
+type machinesDataSourceModel struct {
+	Filters  types.List           `tfsdk:"filters"`
+	Sort     types.String         `tfsdk:"sort"`
+	Reverse  types.Bool           `tfsdk:"reverse"`
+	Limit    types.Int64          `tfsdk:"limit"`
+	Offset   types.Int64          `tfsdk:"offset"`
+	Machines []machineDetailModel `tfsdk:"machines"`
+}
+
+func (d *machinesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "Expected a configured provider before this data source is used.")
+		return
+	}
+	var data machinesDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filters, diags := diagListToStringSlice(ctx, data.Filters)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	params, err := machineFilterParams(filters)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("filters"), "Invalid filters entry", err.Error())
+		return
+	}
+
+	filterArgs := []string{}
+	if s := data.Sort.ValueString(); s != "" {
+		filterArgs = append(filterArgs, "sort", s)
+	}
+	if data.Reverse.ValueBool() {
+		filterArgs = append(filterArgs, "reverse")
+	}
+	if !data.Limit.IsNull() && !data.Limit.IsUnknown() {
+		filterArgs = append(filterArgs, "limit", strconv.FormatInt(data.Limit.ValueInt64(), 10))
+	}
+	if !data.Offset.IsNull() && !data.Offset.IsUnknown() {
+		filterArgs = append(filterArgs, "offset", strconv.FormatInt(data.Offset.ValueInt64(), 10))
+	}
+
+	res := []*models.Machine{}
+	if err := d.client.do(ctx, func() *api.R {
+		return d.client.session.Req().Filter("machines", filterArgs...).Params(params...)
+	}, &res); err != nil {
+		resp.Diagnostics.AddError("List machines failed", err.Error())
+		return
+	}
+
+	data.Machines = make([]machineDetailModel, len(res))
+	for i, mo := range res {
+		flattenMachineDetail(ctx, mo, &data.Machines[i], &resp.Diagnostics)
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
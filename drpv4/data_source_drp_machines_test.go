@@ -0,0 +1,29 @@
+package drpv4
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccMachinesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					data "drp_machines" "test" {
+						filters = ["Pool=Eq(tfpool-%s)"]
+						sort    = "Name"
+						limit   = 10
+					}
+				`, accRandomSuffix(10)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.drp_machines.test", "machines.#", "0"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,233 @@
+package drpv4
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pborman/uuid"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var (
+	_ datasource.DataSource                     = (*renderedTemplateDataSource)(nil)
+	_ datasource.DataSourceWithConfigure        = (*renderedTemplateDataSource)(nil)
+	_ datasource.DataSourceWithConfigValidators = (*renderedTemplateDataSource)(nil)
+)
+
+type renderedTemplateDataSource struct {
+	client *Config
+}
+
+func NewRenderedTemplateDataSource() datasource.DataSource {
+	return &renderedTemplateDataSource{}
+}
+
+func (d *renderedTemplateDataSource) Metadata(_ context.Context, _ datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = "drp_rendered_template"
+}
+
+func (d *renderedTemplateDataSource) ConfigValidators(_ context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.ExactlyOneOf(
+			path.MatchRoot("template_id"),
+			path.MatchRoot("contents"),
+			path.MatchRoot("bootenv_template"),
+		),
+		datasourcevalidator.AtLeastOneOf(
+			path.MatchRoot("machine"),
+			path.MatchRoot("profiles"),
+			path.MatchRoot("params"),
+		),
+		datasourcevalidator.Conflicting(path.MatchRoot("machine"), path.MatchRoot("profiles")),
+		datasourcevalidator.Conflicting(path.MatchRoot("machine"), path.MatchRoot("params")),
+	}
+}
+
+func (d *renderedTemplateDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Renders a template on the DRP server in the context of a machine, or of a set of profiles and params. Without a machine, the template is rendered against a temporary machine that is deleted afterwards.",
+		MarkdownDescription: "Renders a template on the DRP server in the context of a machine, or of a set of profiles and params. Without a `machine`, the template is rendered against a temporary machine that is deleted afterwards.",
+		Attributes: map[string]schema.Attribute{
+			"template_id": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Id of an existing template to render.",
+				MarkdownDescription: "Id of an existing template to render.",
+			},
+			"contents": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Inline template contents to render.",
+				MarkdownDescription: "Inline template contents to render.",
+			},
+			"bootenv_template": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Name of a template entry of bootenv to render, as served to machines booting it.",
+				MarkdownDescription: "Name of a template entry of `bootenv` to render, as served to machines booting it.",
+			},
+			"bootenv": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Bootenv to render in. With machine, defaults to the machine's bootenv and must match it; without machine, the temporary machine is placed in this bootenv.",
+				MarkdownDescription: "Bootenv to render in. With `machine`, defaults to the machine's bootenv and must match it; without `machine`, the temporary machine is placed in this bootenv.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"machine": schema.StringAttribute{
+				Optional:            true,
+				Description:         "UUID of the machine whose render context is used.",
+				MarkdownDescription: "UUID of the machine whose render context is used.",
+			},
+			"profiles": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Profiles of the temporary machine used as render context.",
+				MarkdownDescription: "Profiles of the temporary machine used as render context.",
+			},
+			"params": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Params of the temporary machine used as render context (values are strings; typed per param definition).",
+				MarkdownDescription: "Params of the temporary machine used as render context (values are strings; typed per param definition).",
+			},
+			"rendered": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Rendered output.",
+				MarkdownDescription: "Rendered output.",
+			},
+		},
+	}
+}
+
+func (d *renderedTemplateDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.client = configureDataSourceClient(req, resp)
+}
+
+type renderedTemplateDataSourceModel struct {
+	TemplateID      types.String `tfsdk:"template_id"`
+	Contents        types.String `tfsdk:"contents"`
+	BootEnvTemplate types.String `tfsdk:"bootenv_template"`
+	BootEnv         types.String `tfsdk:"bootenv"`
+	Machine         types.String `tfsdk:"machine"`
+	Profiles        types.List   `tfsdk:"profiles"`
+	Params          types.Map    `tfsdk:"params"`
+	Rendered        types.String `tfsdk:"rendered"`
+}
+
+// bootEnvTemplateContents returns a template that renders the named template entry of a
+// bootenv: a reference to the template it points at, or its inline contents.
+func bootEnvTemplateContents(env *models.BootEnv, name string) (string, error) {
+	for _, ti := range env.Templates {
+		if ti.Name != name {
+			continue
+		}
+		if ti.ID != "" {
+			return fmt.Sprintf("{{ template %q . }}", ti.ID), nil
+		}
+		return ti.Contents, nil
+	}
+	return "", fmt.Errorf("bootenv %s has no template named %s", env.Name, name)
+}
+
+// renderMachine creates the temporary machine used as render context when no machine is
+// given. The caller deletes it once rendering is done.
+func (d *renderedTemplateDataSource) renderMachine(ctx context.Context, data *renderedTemplateDataSourceModel, diags *diag.Diagnostics) *models.Machine {
+	params := convertParamMap(ctx, d.client, data.Params, path.Root("params"), diags)
+	profiles := diagListToStrings(ctx, data.Profiles, diags)
+	if diags.HasError() {
+		return nil
+	}
+	m := &models.Machine{}
+	m.Name = "terraform-render-" + uuid.NewRandom().String()
+	m.BootEnv = data.BootEnv.ValueString()
+	m.Params = params
+	m.Profiles = profiles
+	if err := d.client.createModel(ctx, m); err != nil {
+		diags.AddError("Create render machine failed", err.Error())
+		return nil
+	}
+	tflog.Debug(ctx, "created temporary render machine", map[string]interface{}{"uuid": m.UUID(), "name": m.Name})
+	return m
+}
+
+func (d *renderedTemplateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		return
+	}
+	var data renderedTemplateDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var m *models.Machine
+	if id := data.Machine.ValueString(); id != "" {
+		mo, err := d.client.getModel(ctx, "machines", id)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("machine"), "Render template failed", fmt.Sprintf("unable to get machine %s: %s", id, err))
+			return
+		}
+		m = mo.(*models.Machine)
+		if !data.BootEnv.IsNull() && data.BootEnv.ValueString() != m.BootEnv {
+			resp.Diagnostics.AddAttributeError(path.Root("bootenv"), "Bootenv does not match machine",
+				fmt.Sprintf("Machine %s is in bootenv %s, not %s. Templates render in the machine's own bootenv.", id, m.BootEnv, data.BootEnv.ValueString()))
+			return
+		}
+	} else {
+		m = d.renderMachine(ctx, &data, &resp.Diagnostics)
+		if m == nil {
+			return
+		}
+		defer func() {
+			if _, err := d.client.deleteModel(ctx, "machines", m.UUID()); err != nil && !isNotFound(err) {
+				resp.Diagnostics.AddWarning("Delete render machine failed",
+					fmt.Sprintf("Temporary machine %s (%s) was left behind: %s", m.Name, m.UUID(), err))
+			}
+		}()
+	}
+
+	contents := data.Contents.ValueString()
+	if name := data.BootEnvTemplate.ValueString(); name != "" {
+		env := m.BootEnv
+		if env == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("bootenv"), "Missing bootenv", "bootenv_template needs bootenv, or a machine in a bootenv.")
+			return
+		}
+		eo, err := d.client.getModel(ctx, "bootenvs", env)
+		if err != nil {
+			resp.Diagnostics.AddError("Render template failed", fmt.Sprintf("unable to get bootenv %s: %s", env, err))
+			return
+		}
+		contents, err = bootEnvTemplateContents(eo.(*models.BootEnv), name)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("bootenv_template"), "Render template failed", err.Error())
+			return
+		}
+	}
+
+	machine := m.UUID()
+	var buf bytes.Buffer
+	err := d.client.do(ctx, func() *api.R {
+		buf.Reset()
+		if id := data.TemplateID.ValueString(); id != "" {
+			return d.client.session.Req().Get().UrlFor("templates", id, "render", machine)
+		}
+		return d.client.session.Req().Post([]byte(contents)).UrlFor("machines", machine, "render")
+	}, &buf)
+	if err != nil {
+		resp.Diagnostics.AddError("Render template failed", fmt.Sprintf("machine %s: %s", machine, err))
+		return
+	}
+
+	data.Rendered = types.StringValue(buf.String())
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,243 @@
+package drpv4
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+func TestAccRenderedTemplateDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					data "drp_rendered_template" "test" {
+						machine = "00000000-0000-0000-0000-000000000000"
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: `
+					data "drp_rendered_template" "test" {
+						contents = "{{ .Machine.Name }}"
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: `
+					data "drp_rendered_template" "test" {
+						contents = "{{ .Machine.Name }}"
+						machine  = "00000000-0000-0000-0000-000000000000"
+						profiles = ["global"]
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: `
+					data "drp_rendered_template" "test" {
+						contents = "{{ .Machine.Name }}"
+						machine = "00000000-0000-0000-0000-000000000000"
+					}
+				`,
+				ExpectError: regexp.MustCompile("Render template failed"),
+			},
+			{
+				Config: `
+					resource "drp_profile" "test" {
+						name = "tf-render-test"
+						params = {
+							"tf-render-profile" = "from-profile"
+						}
+					}
+
+					data "drp_rendered_template" "test" {
+						contents = "{{ .Param \"tf-render-profile\" }} {{ .Param \"tf-render-param\" }}"
+						profiles = [drp_profile.test.name]
+						params = {
+							"tf-render-param" = "from-param"
+						}
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.drp_rendered_template.test", "rendered", "from-profile from-param"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_bootenv" "test" {
+						name = "tf-render-test"
+						templates = [{
+							name     = "render.cfg"
+							contents = "mode={{ .Param \"tf-render-param\" }}"
+							path     = "{{.Machine.Path}}/render.cfg"
+						}]
+					}
+
+					data "drp_rendered_template" "test" {
+						bootenv          = drp_bootenv.test.name
+						bootenv_template = "render.cfg"
+						params = {
+							"tf-render-param" = "bootenv"
+						}
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.drp_rendered_template.test", "rendered", "mode=bootenv"),
+				),
+			},
+		},
+	})
+}
+
+// testRenderServer stores created machines, renders by echoing the posted template with the
+// machine's params and profiles, and records deletions.
+type testRenderServer struct {
+	mu       sync.Mutex
+	machines map[string]*models.Machine
+	bootenvs map[string]*models.BootEnv
+	rendered []string
+	deleted  []string
+}
+
+func (s *testRenderServer) handler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v3/"), "/")
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "machines":
+		var m models.Machine
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			writeTestError(w, http.StatusBadRequest)
+			return
+		}
+		m.Uuid = []byte{0x10, 0x20, 0x30, 0x40, 0x50, 0x60, 0x70, 0x80, 0x90, 0xa0, 0xb0, 0xc0, 0xd0, 0xe0, 0xf0, 0x01}
+		s.machines[m.UUID()] = &m
+		json.NewEncoder(w).Encode(&m)
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "machines" && parts[2] == "render":
+		m, ok := s.machines[parts[1]]
+		if !ok {
+			writeTestError(w, http.StatusNotFound)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		s.rendered = append(s.rendered, string(b))
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, string(b)+" "+strings.Join(m.Profiles, ",")+" "+m.BootEnv)
+	case r.Method == http.MethodDelete && len(parts) == 2 && parts[0] == "machines":
+		m, ok := s.machines[parts[1]]
+		if !ok {
+			writeTestError(w, http.StatusNotFound)
+			return
+		}
+		delete(s.machines, parts[1])
+		s.deleted = append(s.deleted, parts[1])
+		json.NewEncoder(w).Encode(m)
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "bootenvs":
+		env, ok := s.bootenvs[parts[1]]
+		if !ok {
+			writeTestError(w, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(env)
+	default:
+		writeTestError(w, http.StatusNotFound)
+	}
+}
+
+func TestRenderedTemplateDataSourceWithoutMachine(t *testing.T) {
+	ctx := context.Background()
+	env := &models.BootEnv{Name: "test-install"}
+	env.Templates = []models.TemplateInfo{
+		{Name: "inline.cfg", Contents: "inline"},
+		{Name: "shared.cfg", ID: "shared.tmpl"},
+	}
+	srv := &testRenderServer{
+		machines: map[string]*models.Machine{},
+		bootenvs: map[string]*models.BootEnv{env.Name: env},
+	}
+	c := testClient(t, srv.handler)
+	d := &renderedTemplateDataSource{client: c}
+	var sresp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &sresp)
+	s := sresp.Schema
+	empty := tftypes.NewValue(s.Type().TerraformType(ctx), nil)
+
+	read := func(data renderedTemplateDataSourceModel) (renderedTemplateDataSourceModel, datasource.ReadResponse) {
+		t.Helper()
+		cfg := tfsdk.State{Schema: s, Raw: empty}
+		if diags := cfg.Set(ctx, &data); diags.HasError() {
+			t.Fatal(diags)
+		}
+		resp := datasource.ReadResponse{State: tfsdk.State{Schema: s, Raw: empty}}
+		d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: s, Raw: cfg.Raw}}, &resp)
+		var got renderedTemplateDataSourceModel
+		if !resp.Diagnostics.HasError() {
+			resp.Diagnostics.Append(resp.State.Get(ctx, &got)...)
+		}
+		return got, resp
+	}
+	model := func() renderedTemplateDataSourceModel {
+		return renderedTemplateDataSourceModel{
+			TemplateID:      types.StringNull(),
+			Contents:        types.StringNull(),
+			BootEnvTemplate: types.StringNull(),
+			BootEnv:         types.StringNull(),
+			Machine:         types.StringNull(),
+			Profiles:        types.ListValueMust(types.StringType, []attr.Value{types.StringValue("p1"), types.StringValue("p2")}),
+			Params:          types.MapNull(types.StringType),
+			Rendered:        types.StringNull(),
+		}
+	}
+
+	data := model()
+	data.Contents = types.StringValue("hello")
+	got, resp := read(data)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if want := "hello p1,p2 "; got.Rendered.ValueString() != want {
+		t.Errorf("rendered = %q, want %q", got.Rendered.ValueString(), want)
+	}
+	if len(srv.deleted) != 1 || len(srv.machines) != 0 {
+		t.Errorf("temporary machine not deleted: deleted %v, left %d", srv.deleted, len(srv.machines))
+	}
+
+	data = model()
+	data.BootEnv = types.StringValue(env.Name)
+	data.BootEnvTemplate = types.StringValue("shared.cfg")
+	got, resp = read(data)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if want := `{{ template "shared.tmpl" . }} p1,p2 test-install`; got.Rendered.ValueString() != want {
+		t.Errorf("rendered = %q, want %q", got.Rendered.ValueString(), want)
+	}
+
+	data.BootEnvTemplate = types.StringValue("missing.cfg")
+	_, resp = read(data)
+	if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), "has no template named missing.cfg") {
+		t.Errorf("missing bootenv template diagnostics = %v", resp.Diagnostics)
+	}
+	if len(srv.deleted) != 3 || len(srv.machines) != 0 {
+		t.Errorf("temporary machines not deleted after an error: deleted %v, left %d", srv.deleted, len(srv.machines))
+	}
+}
@@ -0,0 +1,125 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ ephemeral.EphemeralResource = (*tokenEphemeralResource)(nil)
+var _ ephemeral.EphemeralResourceWithConfigure = (*tokenEphemeralResource)(nil)
+
+type tokenEphemeralResource struct {
+	client *Config
+}
+
+func NewTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &tokenEphemeralResource{}
+}
+
+func (r *tokenEphemeralResource) Metadata(_ context.Context, _ ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = "drp_token"
+}
+
+func (r *tokenEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Issues a short-lived DRP auth token for a user. The token is never stored in state. DRP scopes tokens by role, so define a drp_role with the claims the token needs and pass it in roles.",
+		MarkdownDescription: "Issues a short-lived DRP auth token for a user. The token is never stored in state. DRP scopes tokens by role, so define a `drp_role` with the claims the token needs and pass it in `roles`.",
+		Attributes: map[string]schema.Attribute{
+			"user": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "User the token is issued for. Defaults to the provider username.",
+				MarkdownDescription: "User the token is issued for. Defaults to the provider `username`.",
+			},
+			"ttl": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Token lifetime as a duration (e.g. 15m, 1h). Defaults to the server's token TTL.",
+				MarkdownDescription: "Token lifetime as a duration (e.g. `15m`, `1h`). Defaults to the server's token TTL.",
+			},
+			"roles": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Roles the token is restricted to. The token can never exceed the user's own roles.",
+				MarkdownDescription: "Roles the token is restricted to. The token can never exceed the user's own roles.",
+			},
+			"token": schema.StringAttribute{
+				Computed:            true,
+				Sensitive:           true,
+				Description:         "The issued token.",
+				MarkdownDescription: "The issued token.",
+			},
+		},
+	}
+}
+
+func (r *tokenEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.client = configureEphemeralResourceClient(req, resp)
+}
+
+type tokenEphemeralResourceModel struct {
+	User  types.String `tfsdk:"user"`
+	TTL   types.String `tfsdk:"ttl"`
+	Roles types.List   `tfsdk:"roles"`
+	Token types.String `tfsdk:"token"`
+}
+
+func (r *tokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if r.client == nil {
+		return
+	}
+	var data tokenEphemeralResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	user := data.User.ValueString()
+	if user == "" {
+		user = r.client.username
+	}
+	if user == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("user"), "Missing user",
+			"user must be set when the provider does not authenticate with a username.")
+		return
+	}
+
+	var params []string
+	if ttl := data.TTL.ValueString(); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < time.Second {
+			resp.Diagnostics.AddAttributeError(path.Root("ttl"), "Invalid ttl",
+				fmt.Sprintf("%q is not a duration of at least one second.", ttl))
+			return
+		}
+		params = append(params, "ttl", strconv.Itoa(int(d/time.Second)))
+	}
+	roles := diagListToStrings(ctx, data.Roles, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(roles) > 0 {
+		params = append(params, "roles", strings.Join(roles, ","))
+	}
+
+	token := &models.UserToken{}
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().UrlFor("users", user, "token").Params(params...)
+	}, token); err != nil {
+		resp.Diagnostics.AddError("Issue token failed", fmt.Sprintf("user %s: %s", user, err))
+		return
+	}
+
+	data.User = types.StringValue(user)
+	data.Token = types.StringValue(token.Token)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
@@ -0,0 +1,38 @@
+package drpv4
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccTokenEphemeralResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					ephemeral "drp_token" "test" {
+						user = "rocketskates"
+						ttl = "5m"
+					}
+				`,
+			},
+			{
+				Config: `
+					ephemeral "drp_token" "test" {
+						user = "rocketskates"
+						ttl = "soon"
+					}
+				`,
+				ExpectError: regexp.MustCompile(`Invalid ttl`),
+			},
+		},
+	})
+}
@@ -0,0 +1,73 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+// importMachine resolves a machine import id, either a machine UUID or name:<machine-name>.
+func importMachine(ctx context.Context, c *Config, id string) (*models.Machine, error) {
+	if name, ok := strings.CutPrefix(id, "name:"); ok {
+		mo, err := c.listModel(ctx, "machines", "Name", name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list machines named %s: %w", name, err)
+		}
+		if len(mo) != 1 {
+			return nil, fmt.Errorf("found %d machines named %s, expected 1", len(mo), name)
+		}
+		return mo[0].(*models.Machine), nil
+	}
+	mo, err := c.getModel(ctx, "machines", id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get machine %s: %w", id, err)
+	}
+	return mo.(*models.Machine), nil
+}
+
+// machineAuthorizedKeys returns the keys drp_machine set in the access-keys param, ordered
+// by their terraform-N entry names.
+func machineAuthorizedKeys(m *models.Machine) []string {
+	accessKeys, _ := m.Params["access-keys"].(map[string]interface{})
+	type entry struct {
+		n   int
+		key string
+	}
+	var entries []entry
+	for k, v := range accessKeys {
+		idx, ok := strings.CutPrefix(k, "terraform-")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(idx)
+		if err != nil {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			entries = append(entries, entry{n: n, key: s})
+		}
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return a.n - b.n })
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// importedStringList is null for an empty slice so an import matches a config that omits
+// the list.
+func importedStringList(ctx context.Context, v []string, diags *diag.Diagnostics) types.List {
+	if len(v) == 0 {
+		return types.ListNull(types.StringType)
+	}
+	l, d := types.ListValueFrom(ctx, types.StringType, v)
+	diags.Append(d...)
+	return l
+}
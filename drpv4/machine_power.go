@@ -0,0 +1,52 @@
+package drpv4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/provision/v4/api"
+)
+
+// machinePowerPlugin provides the power actions run on machines.
+const machinePowerPlugin = "ipmi"
+
+// runMachineAction runs a machine action of the IPMI plugin and returns its result.
+func runMachineAction(ctx context.Context, c *Config, uuid, command string) (string, error) {
+	var res interface{}
+	err := c.do(ctx, func() *api.R {
+		return c.session.Req().Post(map[string]interface{}{}).
+			UrlFor("machines", uuid, "actions", command).
+			Params("plugin", machinePowerPlugin)
+	}, &res)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Sprintf("%v", res), nil
+	}
+	return string(b), nil
+}
+
+// runMachineActions runs commands in order, stopping at the first failure. A failure is
+// reported as an error, or as a warning when warnOnly is set; results of successful
+// actions are only logged.
+func runMachineActions(ctx context.Context, c *Config, uuid string, warnOnly bool, diags *diag.Diagnostics, commands ...string) {
+	for _, command := range commands {
+		res, err := runMachineAction(ctx, c, uuid, command)
+		if err != nil {
+			summary := fmt.Sprintf("Machine action %s failed", command)
+			detail := fmt.Sprintf("machine %s: %s", uuid, err)
+			if warnOnly {
+				diags.AddWarning(summary, detail)
+			} else {
+				diags.AddError(summary, detail)
+			}
+			return
+		}
+		tflog.Info(ctx, "ran machine action", map[string]interface{}{"uuid": uuid, "action": command, "result": res})
+	}
+}
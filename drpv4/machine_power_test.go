@@ -0,0 +1,64 @@
+package drpv4
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestRunMachineActions(t *testing.T) {
+	ctx := context.Background()
+	const uuid = "3dc7b5d4-98a4-4c4d-9a31-3a4b8e0c6f11"
+	var mu sync.Mutex
+	var calls []string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		action := strings.TrimPrefix(r.URL.Path, "/api/v3/machines/"+uuid+"/actions/")
+		calls = append(calls, r.Method+" "+action+" plugin="+r.URL.Query().Get("plugin"))
+		if action == "powerOn" {
+			writeTestError(w, http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"` + action + ` done"`))
+	})
+
+	t.Run("successes add no diagnostics", func(t *testing.T) {
+		calls = nil
+		var diags diag.Diagnostics
+		runMachineActions(ctx, c, uuid, false, &diags, "nextbootpxe", "powerOff")
+		want := []string{"POST nextbootpxe plugin=ipmi", "POST powerOff plugin=ipmi"}
+		if strings.Join(calls, ",") != strings.Join(want, ",") {
+			t.Errorf("calls = %v, want %v", calls, want)
+		}
+		if len(diags) != 0 {
+			t.Errorf("got diagnostics %v, want none", diags)
+		}
+	})
+
+	t.Run("failure stops the remaining actions", func(t *testing.T) {
+		calls = nil
+		var diags diag.Diagnostics
+		runMachineActions(ctx, c, uuid, false, &diags, "powerOn", "powerOff")
+		if len(calls) != 1 {
+			t.Errorf("calls = %v, want only powerOn", calls)
+		}
+		if !diags.HasError() || diags.Errors()[0].Summary() != "Machine action powerOn failed" {
+			t.Errorf("diagnostics = %v, want a powerOn failure", diags)
+		}
+	})
+
+	t.Run("failure as a warning", func(t *testing.T) {
+		calls = nil
+		var diags diag.Diagnostics
+		runMachineActions(ctx, c, uuid, true, &diags, "powerOn")
+		if diags.HasError() || diags.WarningsCount() != 1 || diags.Warnings()[0].Summary() != "Machine action powerOn failed" {
+			t.Errorf("diagnostics = %v, want one powerOn failure warning", diags)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package drpv4
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+const jobLogTailLines = 20
+
+// machinePollInterval is how often machines and work orders are polled while waiting.
+var machinePollInterval = 10 * time.Second
+
+// machineWaitOptions describes what waitForMachine considers done. A machine is done
+// once WorkflowComplete is true (when WorkflowComplete is set) or it reaches TargetStage.
+// A failed IgnoreJob is not treated as a failure: it is a job that failed before the
+// wait started and that the machine has not moved past yet.
+type machineWaitOptions struct {
+	WorkflowComplete bool
+	TargetStage      string
+	Timeout          time.Duration
+	IgnoreJob        string
+}
+
+func (o machineWaitOptions) enabled() bool {
+	return o.WorkflowComplete || o.TargetStage != ""
+}
+
+func (o machineWaitOptions) done(m *models.Machine) bool {
+	if o.TargetStage != "" && m.Stage == o.TargetStage {
+		return true
+	}
+	return o.WorkflowComplete && m.WorkflowComplete
+}
+
+// waitForMachine polls the machine until opts are satisfied, the timeout expires, or the
+// current job fails. A failure returns the failing task and the tail of its job log.
+func waitForMachine(ctx context.Context, c *Config, uuid string, opts machineWaitOptions) (*models.Machine, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	for {
+		mo, err := c.getModel(ctx, "machines", uuid)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get machine %s: %w", uuid, err)
+		}
+		m := mo.(*models.Machine)
+		tflog.Debug(ctx, "waiting for machine", map[string]interface{}{
+			"uuid": uuid, "workflow": m.Workflow, "stage": m.Stage, "workflow_complete": m.WorkflowComplete, "job_state": m.JobState,
+		})
+		if m.JobState == "failed" && (opts.IgnoreJob == "" || m.CurrentJob.String() != opts.IgnoreJob) {
+			return m, machineJobError(ctx, c, m)
+		}
+		if opts.done(m) {
+			return m, nil
+		}
+		select {
+		case <-ctx.Done():
+			return m, fmt.Errorf("timed out after %s waiting for machine %s (workflow %q, stage %q)", opts.Timeout, m.Name, m.Workflow, m.Stage)
+		case <-time.After(machinePollInterval):
+		}
+	}
+}
+
+func machineJobError(ctx context.Context, c *Config, m *models.Machine) error {
+	return jobError(ctx, c, "machine "+m.Name, m.CurrentJob.String())
+}
+
+// jobError describes a failed job of subject by its task and the tail of its job log.
+func jobError(ctx context.Context, c *Config, subject, jobID string) error {
+	task := ""
+	if jo, err := c.getModel(ctx, "jobs", jobID); err == nil {
+		task = jo.(*models.Job).Task
+	}
+	var buf bytes.Buffer
+	if err := c.do(ctx, func() *api.R { return c.session.Req().UrlFor("jobs", jobID, "log") }, &buf); err != nil {
+		return fmt.Errorf("%s failed task %q (job %s); unable to fetch job log: %s", subject, task, jobID, err)
+	}
+	return fmt.Errorf("%s failed task %q (job %s), log tail:\n%s", subject, task, jobID, tailLines(buf.String(), jobLogTailLines))
+}
+
+// waitForWorkOrder polls the work order until it reaches state (or finishes), the timeout
+// expires, or it fails or is cancelled. A failure returns the failing task and the tail of
+// its job log.
+func waitForWorkOrder(ctx context.Context, c *Config, uuid, state string, timeout time.Duration) (*models.WorkOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		wo, err := c.getModel(ctx, "work_orders", uuid)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get work order %s: %w", uuid, err)
+		}
+		w := wo.(*models.WorkOrder)
+		tflog.Debug(ctx, "waiting for work order", map[string]interface{}{
+			"uuid": uuid, "blueprint": w.Blueprint, "state": w.State, "status": w.Status,
+		})
+		switch w.State {
+		case "failed":
+			return w, jobError(ctx, c, "work order "+uuid, w.CurrentJob.String())
+		case "cancelled":
+			return w, fmt.Errorf("work order %s (blueprint %q) was cancelled: %s", uuid, w.Blueprint, w.Status)
+		case state, "finished":
+			return w, nil
+		}
+		select {
+		case <-ctx.Done():
+			return w, fmt.Errorf("timed out after %s waiting for work order %s (blueprint %q, state %q)", timeout, uuid, w.Blueprint, w.State)
+		case <-time.After(machinePollInterval):
+		}
+	}
+}
+
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
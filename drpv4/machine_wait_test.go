@@ -0,0 +1,107 @@
+package drpv4
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+// testMachineServer serves a single machine, replaying states in order (the last one
+// repeats) and recording the patches sent to it.
+type testMachineServer struct {
+	mu      sync.Mutex
+	states  []*models.Machine
+	gets    int
+	patches []string
+	job     *models.Job
+}
+
+func (s *testMachineServer) handler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/v3/jobs/") && strings.HasSuffix(r.URL.Path, "/log"):
+		w.Header().Set("Content-Type", "application/octet-stream")
+		io.WriteString(w, "step 1\nstep 2 failed\n")
+	case strings.HasPrefix(r.URL.Path, "/api/v3/jobs/"):
+		json.NewEncoder(w).Encode(s.job)
+	case r.Method == http.MethodPatch:
+		b, _ := io.ReadAll(r.Body)
+		s.patches = append(s.patches, string(b))
+		json.NewEncoder(w).Encode(s.states[0])
+	default:
+		m := s.states[min(s.gets, len(s.states)-1)]
+		s.gets++
+		json.NewEncoder(w).Encode(m)
+	}
+}
+
+func testMachine(workflow, jobState string, job uuid.UUID, complete bool) *models.Machine {
+	m := &models.Machine{}
+	m.Uuid = uuid.Parse("3dc7b5d4-98a4-4c4d-9a31-3a4b8e0c6f11")
+	m.Name = "m1"
+	m.Workflow = workflow
+	m.JobState = jobState
+	m.CurrentJob = job
+	m.WorkflowComplete = complete
+	return m
+}
+
+func TestWaitForMachineIgnoreJob(t *testing.T) {
+	ctx := context.Background()
+	defer func(d time.Duration) { machinePollInterval = d }(machinePollInterval)
+	machinePollInterval = time.Millisecond
+
+	prior, next := uuid.NewRandom(), uuid.NewRandom()
+	opts := machineWaitOptions{WorkflowComplete: true, Timeout: 5 * time.Second, IgnoreJob: prior.String()}
+
+	t.Run("failure of the ignored job", func(t *testing.T) {
+		srv := &testMachineServer{states: []*models.Machine{
+			testMachine("deploy", "failed", prior, false),
+			testMachine("deploy", "running", next, false),
+			testMachine("deploy", "finished", next, true),
+		}}
+		m, err := waitForMachine(ctx, testClient(t, srv.handler), "m1", opts)
+		if err != nil {
+			t.Fatalf("waitForMachine() error = %v", err)
+		}
+		if !m.WorkflowComplete || srv.gets != 3 {
+			t.Errorf("returned after %d polls with WorkflowComplete %v, want 3 polls and true", srv.gets, m.WorkflowComplete)
+		}
+	})
+
+	t.Run("failure of a later job", func(t *testing.T) {
+		srv := &testMachineServer{
+			states: []*models.Machine{
+				testMachine("deploy", "failed", prior, false),
+				testMachine("deploy", "failed", next, false),
+			},
+			job: &models.Job{Uuid: next, Task: "install"},
+		}
+		_, err := waitForMachine(ctx, testClient(t, srv.handler), "m1", opts)
+		if err == nil || !strings.Contains(err.Error(), `failed task "install"`) || !strings.Contains(err.Error(), "step 2 failed") {
+			t.Fatalf("waitForMachine() error = %v, want the failed task and log tail", err)
+		}
+	})
+
+	t.Run("failure without an ignored job", func(t *testing.T) {
+		srv := &testMachineServer{
+			states: []*models.Machine{testMachine("deploy", "failed", prior, false)},
+			job:    &models.Job{Uuid: prior, Task: "install"},
+		}
+		o := opts
+		o.IgnoreJob = ""
+		if _, err := waitForMachine(ctx, testClient(t, srv.handler), "m1", o); err == nil {
+			t.Fatal("waitForMachine() error = nil, want the job failure")
+		}
+	})
+}
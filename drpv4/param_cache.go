@@ -0,0 +1,51 @@
+package drpv4
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+// paramCacheTTL bounds how long a param definition is reused before it is fetched again,
+// so params changed outside of Terraform are picked up during long runs.
+const paramCacheTTL = 5 * time.Minute
+
+type paramCacheEntry struct {
+	param   *models.Param
+	err     error
+	fetched time.Time
+}
+
+// paramCache holds param definitions looked up while converting param values. Not Found
+// results are cached too, so values for undefined params don't cost a lookup each time.
+// The zero value is ready to use.
+type paramCache struct {
+	mu      sync.Mutex
+	entries map[string]paramCacheEntry
+}
+
+func (pc *paramCache) get(name string) (paramCacheEntry, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	e, ok := pc.entries[name]
+	if !ok || time.Since(e.fetched) > paramCacheTTL {
+		return paramCacheEntry{}, false
+	}
+	return e, true
+}
+
+func (pc *paramCache) put(name string, p *models.Param, err error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.entries == nil {
+		pc.entries = map[string]paramCacheEntry{}
+	}
+	pc.entries[name] = paramCacheEntry{param: p, err: err, fetched: time.Now()}
+}
+
+func (pc *paramCache) invalidate(name string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	delete(pc.entries, name)
+}
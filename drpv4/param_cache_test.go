@@ -0,0 +1,192 @@
+package drpv4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+func TestParamCache(t *testing.T) {
+	var pc paramCache
+	if _, ok := pc.get("p1"); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+
+	pc.put("p1", &models.Param{Name: "p1"}, nil)
+	e, ok := pc.get("p1")
+	if !ok || e.param == nil || e.param.Name != "p1" {
+		t.Fatalf("get(p1) = %+v, %v", e, ok)
+	}
+
+	notFound := errors.New("Not Found")
+	pc.put("missing", nil, notFound)
+	e, ok = pc.get("missing")
+	if !ok || e.param != nil || !errors.Is(e.err, notFound) {
+		t.Fatalf("get(missing) = %+v, %v, want the cached error", e, ok)
+	}
+
+	pc.invalidate("p1")
+	if _, ok := pc.get("p1"); ok {
+		t.Error("get(p1) returned an entry after invalidate")
+	}
+	if _, ok := pc.get("missing"); !ok {
+		t.Error("invalidate(p1) dropped the entry for another param")
+	}
+
+	pc.mu.Lock()
+	e = pc.entries["missing"]
+	e.fetched = time.Now().Add(-paramCacheTTL - time.Second)
+	pc.entries["missing"] = e
+	pc.mu.Unlock()
+	if _, ok := pc.get("missing"); ok {
+		t.Error("get(missing) returned an entry older than paramCacheTTL")
+	}
+}
+
+// testParamServer serves param definitions from params and counts the GETs for each one.
+type testParamServer struct {
+	mu     sync.Mutex
+	params map[string]*models.Param
+	gets   map[string]int
+}
+
+func (s *testParamServer) handler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, _ := strings.CutPrefix(r.URL.Path, "/api/v3/params")
+	name = strings.TrimPrefix(name, "/")
+	switch r.Method {
+	case http.MethodGet:
+		s.gets[name]++
+	case http.MethodPost, http.MethodPut:
+		var p models.Param
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeTestError(w, http.StatusBadRequest)
+			return
+		}
+		s.params[p.Name] = &p
+		name = p.Name
+	case http.MethodDelete:
+		if p, ok := s.params[name]; ok {
+			delete(s.params, name)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(p)
+			return
+		}
+	}
+	p, ok := s.params[name]
+	if !ok {
+		writeTestError(w, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+func (s *testParamServer) getCount(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets[name]
+}
+
+func TestGetParamCaching(t *testing.T) {
+	ctx := context.Background()
+	srv := &testParamServer{
+		params: map[string]*models.Param{"p1": {Name: "p1", Schema: map[string]interface{}{"type": "integer"}}},
+		gets:   map[string]int{},
+	}
+	c := testClient(t, srv.handler)
+
+	for i := 0; i < 3; i++ {
+		if got := getParamSchemaType(ctx, c, "p1"); got != "integer" {
+			t.Fatalf("getParamSchemaType(p1) = %q, want integer", got)
+		}
+		if _, err := getParam(ctx, c, "missing"); !isNotFound(err) {
+			t.Fatalf("getParam(missing) error = %v, want Not Found", err)
+		}
+	}
+	if n := srv.getCount("p1"); n != 1 {
+		t.Errorf("fetched p1 %d times, want 1", n)
+	}
+	if n := srv.getCount("missing"); n != 1 {
+		t.Errorf("fetched missing %d times, want 1 (Not Found is cached)", n)
+	}
+
+	c.params.invalidate("p1")
+	getParam(ctx, c, "p1")
+	if n := srv.getCount("p1"); n != 2 {
+		t.Errorf("fetched p1 %d times after invalidate, want 2", n)
+	}
+}
+
+func TestParamResourceInvalidatesCache(t *testing.T) {
+	ctx := context.Background()
+	srv := &testParamServer{params: map[string]*models.Param{}, gets: map[string]int{}}
+	c := testClient(t, srv.handler)
+	r := &paramResource{client: c}
+	var sresp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &sresp)
+	s := sresp.Schema
+
+	model := func(typ string) *paramResourceModel {
+		return &paramResourceModel{
+			Name:          types.StringValue("p1"),
+			Description:   types.StringNull(),
+			Documentation: types.StringNull(),
+			Schema:        types.MapValueMust(types.StringType, map[string]attr.Value{"type": types.StringValue(typ)}),
+			Secure:        types.BoolNull(),
+		}
+	}
+	empty := tftypes.NewValue(s.Type().TerraformType(ctx), nil)
+
+	// Cache the Not Found result for p1 before the resource creates it.
+	if _, err := getParam(ctx, c, "p1"); !isNotFound(err) {
+		t.Fatalf("getParam(p1) error = %v, want Not Found", err)
+	}
+
+	plan := tfsdk.Plan{Schema: s, Raw: empty}
+	if d := plan.Set(ctx, model("integer")); d.HasError() {
+		t.Fatal(d)
+	}
+	cresp := resource.CreateResponse{State: tfsdk.State{Schema: s, Raw: empty}}
+	r.Create(ctx, resource.CreateRequest{Plan: plan}, &cresp)
+	if cresp.Diagnostics.HasError() {
+		t.Fatal(cresp.Diagnostics)
+	}
+	if got := getParamSchemaType(ctx, c, "p1"); got != "integer" {
+		t.Errorf("after create getParamSchemaType(p1) = %q, want integer", got)
+	}
+
+	if d := plan.Set(ctx, model("object")); d.HasError() {
+		t.Fatal(d)
+	}
+	uresp := resource.UpdateResponse{State: cresp.State}
+	r.Update(ctx, resource.UpdateRequest{Plan: plan, State: cresp.State}, &uresp)
+	if uresp.Diagnostics.HasError() {
+		t.Fatal(uresp.Diagnostics)
+	}
+	if got := getParamSchemaType(ctx, c, "p1"); got != "object" {
+		t.Errorf("after update getParamSchemaType(p1) = %q, want object", got)
+	}
+
+	var dresp resource.DeleteResponse
+	r.Delete(ctx, resource.DeleteRequest{State: uresp.State}, &dresp)
+	if dresp.Diagnostics.HasError() {
+		t.Fatal(dresp.Diagnostics)
+	}
+	if _, err := getParam(ctx, c, "p1"); !isNotFound(err) {
+		t.Errorf("after delete getParam(p1) error = %v, want Not Found", err)
+	}
+}
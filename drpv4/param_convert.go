@@ -1,22 +1,40 @@
 package drpv4
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/api"
 	"gitlab.com/rackn/provision/v4/models"
 )
 
-func getParam(c *Config, name string) (*models.Param, error) {
+// getParam returns the param definition for name, served from the provider param cache
+// when a recent lookup exists.
+func getParam(ctx context.Context, c *Config, name string) (*models.Param, error) {
+	if e, ok := c.params.get(name); ok {
+		return e.param, e.err
+	}
 	var p *models.Param
-	if err := c.session.Req().UrlFor("params", name).Do(&p); err != nil {
+	if err := c.do(ctx, func() *api.R { return c.session.Req().UrlFor("params", name) }, &p); err != nil {
+		var e *models.Error
+		if errors.As(err, &e) && e.Code == http.StatusNotFound {
+			c.params.put(name, nil, err)
+		}
 		return nil, err
 	}
+	c.params.put(name, p, nil)
 	return p, nil
 }
 
-func getParamSchemaType(c *Config, name string) string {
-	param, err := getParam(c, name)
+func getParamSchemaType(ctx context.Context, c *Config, name string) string {
+	param, err := getParam(ctx, c, name)
 	if err != nil {
 		return ""
 	}
@@ -34,8 +52,8 @@ func getParamSchemaType(c *Config, name string) string {
 	return s
 }
 
-func convertParamToType(c *Config, name string, value string) (interface{}, error) {
-	paramType := getParamSchemaType(c, name)
+func convertParamToType(ctx context.Context, c *Config, name string, value string) (interface{}, error) {
+	paramType := getParamSchemaType(ctx, c, name)
 	switch paramType {
 	case "string":
 		return value, nil
@@ -48,6 +66,26 @@ func convertParamToType(c *Config, name string, value string) (interface{}, erro
 	}
 }
 
+// convertParamMap converts a map attribute of string values into param values typed
+// per param definition. Errors are reported against attrPath.
+func convertParamMap(ctx context.Context, c *Config, m types.Map, attrPath path.Path, diags *diag.Diagnostics) map[string]interface{} {
+	if m.IsNull() || m.IsUnknown() {
+		return nil
+	}
+	var sm map[string]string
+	diags.Append(m.ElementsAs(ctx, &sm, false)...)
+	out := make(map[string]interface{}, len(sm))
+	for k, v := range sm {
+		val, err := convertParamToType(ctx, c, k, v)
+		if err != nil {
+			diags.AddAttributeError(attrPath.AtMapKey(k), "Invalid param value", err.Error())
+			return nil
+		}
+		out[k] = val
+	}
+	return out
+}
+
 func convertParamToString(value interface{}) (string, error) {
 	switch v := value.(type) {
 	case string:
@@ -58,18 +96,17 @@ func convertParamToString(value interface{}) (string, error) {
 	}
 }
 
-func isParamSecure(c *Config, name string) bool {
-	res, err := c.session.GetModel("params", name)
+func isParamSecure(ctx context.Context, c *Config, name string) bool {
+	param, err := getParam(ctx, c, name)
 	if err != nil {
 		return false
 	}
-	param := res.(*models.Param)
 	return param.Secure
 }
 
-func getPublicKey(c *Config, profile string) ([]byte, error) {
+func getPublicKey(ctx context.Context, c *Config, profile string) ([]byte, error) {
 	var pubkey []byte
-	if err := c.session.Req().UrlFor("profiles", profile, "pubkey").Do(&pubkey); err != nil {
+	if err := c.do(ctx, func() *api.R { return c.session.Req().UrlFor("profiles", profile, "pubkey") }, &pubkey); err != nil {
 		return nil, err
 	}
 	return pubkey, nil
@@ -109,3 +146,34 @@ func interfaceMapToStringMap(m map[string]interface{}) (map[string]string, error
 	}
 	return out, nil
 }
+
+// keepEquivalentParamStrings replaces values in sm, read back from the API, with the prior
+// string for the same key when both decode to the same JSON value. convertParamMap decodes
+// values of non-string params, so "1.0" or a spaced JSON object would otherwise come back
+// re-marshalled and differ from the configuration.
+func keepEquivalentParamStrings(prior types.Map, sm map[string]string) {
+	if prior.IsNull() || prior.IsUnknown() {
+		return
+	}
+	for k, el := range prior.Elements() {
+		s, ok := el.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+		v, ok := sm[k]
+		if !ok || v == s.ValueString() {
+			continue
+		}
+		if jsonEquivalent(s.ValueString(), v) {
+			sm[k] = s.ValueString()
+		}
+	}
+}
+
+func jsonEquivalent(a, b string) bool {
+	var av, bv interface{}
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
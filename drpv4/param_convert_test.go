@@ -0,0 +1,47 @@
+package drpv4
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestKeepEquivalentParamStrings(t *testing.T) {
+	prior := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"obj":     types.StringValue(`{ "a": 1 }`),
+		"number":  types.StringValue("1.0"),
+		"changed": types.StringValue(`{"a": 1}`),
+		"text":    types.StringValue("hello"),
+		"gone":    types.StringValue("1"),
+	})
+	sm := map[string]string{
+		"obj":     `{"a":1}`,
+		"number":  "1",
+		"changed": `{"a":2}`,
+		"text":    "goodbye",
+		"new":     "2",
+	}
+	keepEquivalentParamStrings(prior, sm)
+	want := map[string]string{
+		"obj":     `{ "a": 1 }`,
+		"number":  "1.0",
+		"changed": `{"a":2}`,
+		"text":    "goodbye",
+		"new":     "2",
+	}
+	if len(sm) != len(want) {
+		t.Fatalf("got %d params, want %d: %v", len(sm), len(want), sm)
+	}
+	for k, v := range want {
+		if sm[k] != v {
+			t.Errorf("params[%s] = %q, want %q", k, sm[k], v)
+		}
+	}
+
+	sm = map[string]string{"obj": `{"a":1}`}
+	keepEquivalentParamStrings(types.MapNull(types.StringType), sm)
+	if sm["obj"] != `{"a":1}` {
+		t.Errorf("null prior changed params[obj] to %q", sm["obj"])
+	}
+}
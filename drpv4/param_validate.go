@@ -0,0 +1,83 @@
+package drpv4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/xeipuuv/gojsonschema"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+// paramSchemaViolations checks value against the JSON schema of the param definition and
+// returns one message per violation. Params without a definition or schema accept any
+// value, as do templated strings since DRP only expands those at render time.
+func paramSchemaViolations(ctx context.Context, c *Config, name string, value interface{}) ([]string, error) {
+	p, err := getParam(ctx, c, name)
+	if err != nil {
+		var e *models.Error
+		if errors.As(err, &e) && e.Code == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if p.Schema == nil {
+		return nil, nil
+	}
+	if s, ok := value.(string); ok && strings.Contains(s, "{{") {
+		return nil, nil
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(p.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("param %s has an invalid schema: %w", name, err)
+	}
+	res, err := schema.Validate(gojsonschema.NewGoLoader(value))
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range res.Errors() {
+		out = append(out, e.String())
+	}
+	return out, nil
+}
+
+// validateParamValue reports schema violations of a single param value against attrPath.
+// A param definition that cannot be fetched only produces a warning.
+func validateParamValue(ctx context.Context, c *Config, name, value string, attrPath path.Path, diags *diag.Diagnostics) {
+	val, err := convertParamToType(ctx, c, name, value)
+	if err != nil {
+		diags.AddAttributeError(attrPath, "Invalid param value", err.Error())
+		return
+	}
+	violations, err := paramSchemaViolations(ctx, c, name, val)
+	if err != nil {
+		diags.AddAttributeWarning(attrPath, "Unable to validate param value", fmt.Sprintf("param %s: %s", name, err))
+		return
+	}
+	for _, v := range violations {
+		diags.AddAttributeError(attrPath, "Invalid param value", fmt.Sprintf("param %s: %s", name, v))
+	}
+}
+
+// validateParamMap validates every known value of a map of param name to value.
+func validateParamMap(ctx context.Context, c *Config, m types.Map, attrPath path.Path, diags *diag.Diagnostics) {
+	if m.IsNull() || m.IsUnknown() {
+		return
+	}
+	elems := m.Elements()
+	for _, k := range slices.Sorted(maps.Keys(elems)) {
+		sv, ok := elems[k].(types.String)
+		if !ok || sv.IsNull() || sv.IsUnknown() {
+			continue
+		}
+		validateParamValue(ctx, c, k, sv.ValueString(), attrPath.AtMapKey(k), diags)
+	}
+}
@@ -0,0 +1,107 @@
+package drpv4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+// allocatePoolMachines calls pools/<pool>/allocateMachines with the given pool/* parameters.
+func allocatePoolMachines(ctx context.Context, c *Config, pool string, parms map[string]interface{}) ([]*models.PoolResult, error) {
+	pr := []*models.PoolResult{}
+	if err := c.do(ctx, func() *api.R {
+		return c.session.Req().Post(parms).UrlFor("pools", pool, "allocateMachines")
+	}, &pr); err != nil {
+		tflog.Debug(ctx, "allocateMachines failed", map[string]interface{}{"pool": pool, "error": err.Error()})
+		return nil, err
+	}
+	return pr, nil
+}
+
+// releasePoolMachines calls pools/<pool>/releaseMachines with the given pool/* parameters.
+func releasePoolMachines(ctx context.Context, c *Config, pool string, parms map[string]interface{}) ([]*models.PoolResult, error) {
+	pr := []*models.PoolResult{}
+	if err := c.do(ctx, func() *api.R {
+		return c.session.Req().Post(parms).UrlFor("pools", pool, "releaseMachines")
+	}, &pr); err != nil {
+		tflog.Debug(ctx, "releaseMachines failed", map[string]interface{}{"pool": pool, "error": err.Error()})
+		return nil, err
+	}
+	return pr, nil
+}
+
+// poolPollInterval is how often a pool's status is checked while waiting for capacity.
+var poolPollInterval = 10 * time.Second
+
+// poolFreeCount returns the number of Free machines pools/<pool>/status reports.
+func poolFreeCount(ctx context.Context, c *Config, pool string) (int, error) {
+	res := models.PoolResults{}
+	if err := c.do(ctx, func() *api.R {
+		return c.session.Req().UrlFor("pools", pool, "status")
+	}, &res); err != nil {
+		return 0, err
+	}
+	return len(res[models.PS_FREE]), nil
+}
+
+// isPoolCapacityError reports whether err is the pool failing an allocation because it has
+// no free machine to hand out.
+func isPoolCapacityError(err error) bool {
+	var e *models.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, msg := range e.Messages {
+		if strings.Contains(strings.ToLower(msg), "allocation failed") {
+			return true
+		}
+	}
+	return false
+}
+
+// allocatePoolMachinesWait retries allocatePoolMachines until it returns a machine or the
+// timeout expires, polling the pool status between attempts so it only retries once the
+// pool reports a Free machine. Only an empty result or the pool's allocation failure is
+// retried; any other error, such as an invalid filter or a missing pool, fails at once.
+func allocatePoolMachinesWait(ctx context.Context, c *Config, pool string, parms map[string]interface{}, timeout time.Duration) ([]*models.PoolResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		pr, err := allocatePoolMachines(ctx, c, pool, parms)
+		if err == nil && len(pr) > 0 {
+			return pr, nil
+		}
+		if err != nil && !isPoolCapacityError(err) {
+			return nil, err
+		}
+		reason := "pool returned no machines"
+		if err != nil {
+			reason = err.Error()
+		}
+		tflog.Info(ctx, "allocation failed, waiting for pool capacity", map[string]interface{}{"pool": pool, "attempt": attempt, "reason": reason})
+		for free := 0; free == 0; {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("timed out after %s waiting for a free machine in pool %s: %s", timeout, pool, reason)
+			case <-time.After(poolPollInterval):
+			}
+			if free, err = poolFreeCount(ctx, c, pool); err != nil {
+				tflog.Debug(ctx, "pool status failed", map[string]interface{}{"pool": pool, "error": err.Error()})
+			}
+		}
+	}
+}
+
+func poolResultUUIDs(pr []*models.PoolResult) []string {
+	out := make([]string, 0, len(pr))
+	for _, p := range pr {
+		out = append(out, p.Uuid)
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package drpv4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+func TestAllocatePoolMachinesWait(t *testing.T) {
+	ctx := context.Background()
+	defer func(d time.Duration) { poolPollInterval = d }(poolPollInterval)
+	poolPollInterval = time.Millisecond
+
+	capacity := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"Type":"POST","Code":400,"Messages":["Allocation failed: no machines available"]}`)
+	}
+	empty := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	}
+	status := func(code int) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) { writeTestError(w, code) }
+	}
+
+	cases := []struct {
+		name      string
+		responses []func(w http.ResponseWriter) // allocateMachines responses before one succeeds
+		wantCalls int
+		wantErr   string
+	}{
+		{name: "allocated at once", wantCalls: 1},
+		{name: "allocation failure is retried", responses: []func(http.ResponseWriter){capacity, capacity}, wantCalls: 3},
+		{name: "empty result is retried", responses: []func(http.ResponseWriter){empty}, wantCalls: 2},
+		{name: "invalid filter fails at once", responses: []func(http.ResponseWriter){status(http.StatusBadRequest)}, wantCalls: 1, wantErr: "Bad Request"},
+		{name: "unauthorized fails at once", responses: []func(http.ResponseWriter){status(http.StatusUnauthorized)}, wantCalls: 1, wantErr: "Unauthorized"},
+		{name: "forbidden fails at once", responses: []func(http.ResponseWriter){status(http.StatusForbidden)}, wantCalls: 1, wantErr: "Forbidden"},
+		{name: "missing pool fails at once", responses: []func(http.ResponseWriter){status(http.StatusNotFound)}, wantCalls: 1, wantErr: "Not Found"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls, polls := 0, 0
+			c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				switch r.URL.Path {
+				case "/api/v3/pools/p1/status":
+					polls++
+					// Report a free machine on every other poll.
+					res := models.PoolResults{}
+					if polls%2 == 0 {
+						res[models.PS_FREE] = []*models.PoolResult{{Uuid: "m1", Status: models.PS_FREE}}
+					}
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(res)
+				case "/api/v3/pools/p1/allocateMachines":
+					calls++
+					if calls <= len(tc.responses) {
+						tc.responses[calls-1](w)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode([]*models.PoolResult{{Uuid: "m1", Status: models.PS_IN_USE}})
+				default:
+					writeTestError(w, http.StatusNotFound)
+				}
+			})
+			pr, err := allocatePoolMachinesWait(ctx, c, "p1", map[string]interface{}{}, 5*time.Second)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("allocatePoolMachinesWait() error = %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil || len(pr) != 1 || pr[0].Uuid != "m1" {
+				t.Fatalf("allocatePoolMachinesWait() = %v, %v, want machine m1", pr, err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("allocateMachines called %d times, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+
+	t.Run("times out while the pool has no free machine", func(t *testing.T) {
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v3/pools/p1/status" {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{}`)
+				return
+			}
+			capacity(w)
+		})
+		_, err := allocatePoolMachinesWait(ctx, c, "p1", map[string]interface{}{}, 50*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "timed out after 50ms waiting for a free machine in pool p1") {
+			t.Fatalf("allocatePoolMachinesWait() error = %v, want a timeout", err)
+		}
+	})
+}
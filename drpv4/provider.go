@@ -8,16 +8,25 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+var _ provider.ProviderWithEphemeralResources = (*fwProvider)(nil)
+
 type fwProvider struct {
 	version string
 }
@@ -28,6 +37,18 @@ type providerModel struct {
 	Username types.String `tfsdk:"username"`
 	Password types.String `tfsdk:"password"`
 	Endpoint types.String `tfsdk:"endpoint"`
+
+	CACertPEM          types.String `tfsdk:"ca_cert_pem"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+
+	MaxRetries   types.Int64  `tfsdk:"max_retries"`
+	RetryMinWait types.String `tfsdk:"retry_min_wait"`
+	RetryMaxWait types.String `tfsdk:"retry_max_wait"`
+
+	ValidateReferences types.Bool `tfsdk:"validate_references"`
 }
 
 func NewProvider(version string) func() provider.Provider {
@@ -72,6 +93,63 @@ func (p *fwProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *p
 				Description:         "The DRP server URL, for example https://1.2.3.4:8092",
 				MarkdownDescription: "The DRP server URL, for example https://1.2.3.4:8092",
 			},
+			"ca_cert_pem": schema.StringAttribute{
+				Optional:            true,
+				Description:         "PEM encoded CA bundle used to verify the DRP server (or RS_CA_CERT_PEM)",
+				MarkdownDescription: "PEM encoded CA bundle used to verify the DRP server (or `RS_CA_CERT_PEM`)",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("ca_cert_file")),
+				},
+			},
+			"ca_cert_file": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Path to a PEM encoded CA bundle used to verify the DRP server (or RS_CA_CERT_FILE)",
+				MarkdownDescription: "Path to a PEM encoded CA bundle used to verify the DRP server (or `RS_CA_CERT_FILE`)",
+			},
+			"client_cert": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Path to a PEM encoded client certificate used to authenticate to DRP (or RS_CLIENT_CERT)",
+				MarkdownDescription: "Path to a PEM encoded client certificate used to authenticate to DRP (or `RS_CLIENT_CERT`)",
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_key")),
+				},
+			},
+			"client_key": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Path to the PEM encoded private key for client_cert (or RS_CLIENT_KEY)",
+				MarkdownDescription: "Path to the PEM encoded private key for `client_cert` (or `RS_CLIENT_KEY`)",
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_cert")),
+				},
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Skip verification of the DRP server certificate (or RS_INSECURE_SKIP_VERIFY). Defaults to true unless a CA bundle is set",
+				MarkdownDescription: "Skip verification of the DRP server certificate (or `RS_INSECURE_SKIP_VERIFY`). Defaults to true unless a CA bundle is set",
+			},
+			"max_retries": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Number of times a DRP API call that failed with a transient error is retried (or RS_MAX_RETRIES). Defaults to 3",
+				MarkdownDescription: "Number of times a DRP API call that failed with a transient error is retried (or `RS_MAX_RETRIES`). Defaults to 3",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_min_wait": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Wait before the first retry, doubled on each further retry, e.g. 1s (or RS_RETRY_MIN_WAIT). Defaults to 1s",
+				MarkdownDescription: "Wait before the first retry, doubled on each further retry, e.g. `1s` (or `RS_RETRY_MIN_WAIT`). Defaults to `1s`",
+			},
+			"retry_max_wait": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Longest wait between retries, e.g. 30s (or RS_RETRY_MAX_WAIT). Defaults to 30s",
+				MarkdownDescription: "Longest wait between retries, e.g. `30s` (or `RS_RETRY_MAX_WAIT`). Defaults to `30s`",
+			},
+			"validate_references": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Check during plan that stages, tasks, profiles, bootenvs, workflows and pools referenced by name exist on the server (or RS_VALIDATE_REFERENCES). References only known after apply are not checked. Defaults to false",
+				MarkdownDescription: "Check during plan that stages, tasks, profiles, bootenvs, workflows and pools referenced by name exist on the server (or `RS_VALIDATE_REFERENCES`). References only known after apply are not checked. Defaults to `false`",
+			},
 		},
 	}
 }
@@ -98,6 +176,88 @@ func (p *fwProvider) Configure(ctx context.Context, req provider.ConfigureReques
 		endpoint = os.Getenv("RS_ENDPOINT")
 	}
 
+	caCertPEM := data.CACertPEM.ValueString()
+	if caCertPEM == "" {
+		caCertPEM = os.Getenv("RS_CA_CERT_PEM")
+	}
+	caCertFile := data.CACertFile.ValueString()
+	if caCertFile == "" {
+		caCertFile = os.Getenv("RS_CA_CERT_FILE")
+	}
+	clientCert := data.ClientCert.ValueString()
+	if clientCert == "" {
+		clientCert = os.Getenv("RS_CLIENT_CERT")
+	}
+	clientKey := data.ClientKey.ValueString()
+	if clientKey == "" {
+		clientKey = os.Getenv("RS_CLIENT_KEY")
+	}
+	// Keep the historical default of not verifying the server unless a CA bundle is
+	// supplied, since DRP ships with a self-signed certificate.
+	insecure := caCertPEM == "" && caCertFile == ""
+	if !data.InsecureSkipVerify.IsNull() {
+		insecure = data.InsecureSkipVerify.ValueBool()
+	} else if v := os.Getenv("RS_INSECURE_SKIP_VERIFY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			resp.Diagnostics.AddError("Malformed RS_INSECURE_SKIP_VERIFY", fmt.Sprintf("expected a boolean, got %q", v))
+			return
+		}
+		insecure = b
+	}
+
+	retry := defaultRetryPolicy()
+	if !data.MaxRetries.IsNull() {
+		retry.maxRetries = int(data.MaxRetries.ValueInt64())
+	} else if v := os.Getenv("RS_MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			resp.Diagnostics.AddError("Malformed RS_MAX_RETRIES", fmt.Sprintf("expected a non-negative integer, got %q", v))
+			return
+		}
+		retry.maxRetries = n
+	}
+	minWait := data.RetryMinWait.ValueString()
+	if minWait == "" {
+		minWait = os.Getenv("RS_RETRY_MIN_WAIT")
+	}
+	if minWait != "" {
+		d, err := time.ParseDuration(minWait)
+		if err != nil || d <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("retry_min_wait"), "Malformed retry_min_wait", fmt.Sprintf("expected a positive duration, got %q", minWait))
+			return
+		}
+		retry.minWait = d
+	}
+	maxWait := data.RetryMaxWait.ValueString()
+	if maxWait == "" {
+		maxWait = os.Getenv("RS_RETRY_MAX_WAIT")
+	}
+	if maxWait != "" {
+		d, err := time.ParseDuration(maxWait)
+		if err != nil || d <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("retry_max_wait"), "Malformed retry_max_wait", fmt.Sprintf("expected a positive duration, got %q", maxWait))
+			return
+		}
+		retry.maxWait = d
+	}
+	if retry.minWait > retry.maxWait {
+		resp.Diagnostics.AddError("Invalid retry waits", "retry_min_wait cannot be longer than retry_max_wait.")
+		return
+	}
+
+	validateRefs := false
+	if !data.ValidateReferences.IsNull() {
+		validateRefs = data.ValidateReferences.ValueBool()
+	} else if v := os.Getenv("RS_VALIDATE_REFERENCES"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			resp.Diagnostics.AddError("Malformed RS_VALIDATE_REFERENCES", fmt.Sprintf("expected a boolean, got %q", v))
+			return
+		}
+		validateRefs = b
+	}
+
 	if key != "" {
 		parts := strings.SplitN(key, ":", 2)
 		if len(parts) < 2 {
@@ -113,11 +273,35 @@ func (p *fwProvider) Configure(ctx context.Context, req provider.ConfigureReques
 		return
 	}
 
+	if caCertPEM != "" && caCertFile != "" {
+		resp.Diagnostics.AddError("Conflicting CA bundles", "ca_cert_pem cannot be set together with ca_cert_file.")
+		return
+	}
+	if (clientCert == "") != (clientKey == "") {
+		resp.Diagnostics.AddError("Incomplete client certificate", "client_cert and client_key must be set together.")
+		return
+	}
+	if clientCert != "" && (token != "" || username != "") {
+		resp.Diagnostics.AddError(
+			"Conflicting credentials",
+			"client_cert authenticates on its own and cannot be set together with token, key or username.",
+		)
+		return
+	}
+
 	cfg := &Config{
-		token:    token,
-		username: username,
-		password: password,
-		endpoint: endpoint,
+		token:        token,
+		username:     username,
+		password:     password,
+		endpoint:     endpoint,
+		caCertPEM:    caCertPEM,
+		caCertFile:   caCertFile,
+		clientCert:   clientCert,
+		clientKey:    clientKey,
+		serverVerify: !insecure,
+		retry:        retry,
+
+		validateReferences: validateRefs,
 	}
 
 	if cfg.endpoint == "" {
@@ -127,10 +311,10 @@ func (p *fwProvider) Configure(ctx context.Context, req provider.ConfigureReques
 		)
 		return
 	}
-	if cfg.token == "" && cfg.username == "" {
+	if cfg.token == "" && cfg.username == "" && cfg.clientCert == "" {
 		resp.Diagnostics.AddError(
 			"Malformed DRP credentials",
-			"The key, token, or username/password attributes must be provided.",
+			"The key, token, username/password or client_cert/client_key attributes must be provided.",
 		)
 		return
 	}
@@ -153,17 +337,28 @@ func (p *fwProvider) Configure(ctx context.Context, req provider.ConfigureReques
 
 	tflog.Info(ctx, fmt.Sprintf("Digital Rebar %v (features: %v)", info.Version, info.Features))
 	resp.ResourceData = cfg
+	resp.DataSourceData = cfg
+	resp.EphemeralResourceData = cfg
 }
 
 func (p *fwProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewMachineResource,
+		NewMachineGroupResource,
 		NewMachineSetPoolResource,
 		NewParamResource,
 		NewTemplateResource,
 		NewTaskResource,
 		NewStageResource,
 		NewWorkflowResource,
+		NewWorkflowRunResource,
+		NewBlueprintResource,
+		NewWorkOrderResource,
+		NewBootEnvResource,
+		NewContentResource,
+		NewUserResource,
+		NewRoleResource,
+		NewTenantResource,
 		NewSubnetResource,
 		NewReservationResource,
 		NewPoolResource,
@@ -173,5 +368,15 @@ func (p *fwProvider) Resources(_ context.Context) []func() resource.Resource {
 }
 
 func (p *fwProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewMachineDataSource,
+		NewMachinesDataSource,
+		NewRenderedTemplateDataSource,
+	}
+}
+
+func (p *fwProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewTokenEphemeralResource,
+	}
 }
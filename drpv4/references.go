@@ -0,0 +1,39 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// validateReference checks, when validate_references is enabled, that the prefix object
+// named by v exists. Unknown values are skipped since the object may be created in the same
+// apply; lookup failures other than not found only produce a warning.
+func validateReference(ctx context.Context, c *Config, prefix string, v types.String, attrPath path.Path, diags *diag.Diagnostics) {
+	if !c.validateReferences || v.IsNull() || v.IsUnknown() || v.ValueString() == "" {
+		return
+	}
+	key := v.ValueString()
+	if _, err := c.getModel(ctx, prefix, key); err != nil {
+		if isNotFound(err) {
+			diags.AddAttributeError(attrPath, "Unknown reference", fmt.Sprintf("%s/%s does not exist on the server.", prefix, key))
+			return
+		}
+		diags.AddAttributeWarning(attrPath, "Unable to validate reference", fmt.Sprintf("%s/%s: %s", prefix, key, err))
+	}
+}
+
+// validateReferenceList runs validateReference on every element of a list of names.
+func validateReferenceList(ctx context.Context, c *Config, prefix string, l types.List, attrPath path.Path, diags *diag.Diagnostics) {
+	if !c.validateReferences || l.IsNull() || l.IsUnknown() {
+		return
+	}
+	for i, el := range l.Elements() {
+		if s, ok := el.(types.String); ok {
+			validateReference(ctx, c, prefix, s, attrPath.AtListIndex(i), diags)
+		}
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"gitlab.com/rackn/provision/v4/models"
 )
 
 func providerData(cfg any, diags *diag.Diagnostics) *Config {
@@ -37,6 +40,39 @@ func configureResourceClient(req resource.ConfigureRequest, resp *resource.Confi
 	return providerData(req.ProviderData, &resp.Diagnostics)
 }
 
+func configureDataSourceClient(req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) *Config {
+	// Same as configureResourceClient: ValidateDataSourceConfig may run before the provider is configured.
+	if req.ProviderData == nil {
+		return nil
+	}
+	return providerData(req.ProviderData, &resp.Diagnostics)
+}
+
+func configureEphemeralResourceClient(req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) *Config {
+	// Same as configureResourceClient: ValidateEphemeralResourceConfig may run before the provider is configured.
+	if req.ProviderData == nil {
+		return nil
+	}
+	return providerData(req.ProviderData, &resp.Diagnostics)
+}
+
+// warnIfContentOwned warns when obj is read-only because it ships in a content pack, since
+// DRP rejects any change Terraform makes to it. Such objects belong in drp_content.
+func warnIfContentOwned(obj models.Model, diags *diag.Diagnostics) {
+	a, ok := obj.(models.Accessor)
+	if !ok || !a.IsReadOnly() {
+		return
+	}
+	bundle := "a content pack"
+	if b, ok := obj.(models.Bundler); ok && b.GetBundle() != "" {
+		bundle = "content pack " + b.GetBundle()
+	}
+	diags.AddWarning(
+		"Read-only "+obj.Prefix()+" object",
+		fmt.Sprintf("%s/%s belongs to %s and cannot be changed through this resource; manage the pack with drp_content instead.", obj.Prefix(), obj.Key(), bundle),
+	)
+}
+
 func isNotFound(err error) bool {
 	if err == nil {
 		return false
@@ -0,0 +1,225 @@
+package drpv4
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*blueprintResource)(nil)
+var _ resource.ResourceWithImportState = (*blueprintResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*blueprintResource)(nil)
+
+type blueprintResource struct {
+	client *Config
+}
+
+func NewBlueprintResource() resource.Resource {
+	return &blueprintResource{}
+}
+
+func (r *blueprintResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_blueprint"
+}
+
+func (r *blueprintResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "A blueprint is an ordered list of tasks run as a work order on machines in work-order mode.",
+		MarkdownDescription: "A blueprint is an ordered list of tasks run as a work order on machines in work-order mode.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "Blueprint name.",
+				MarkdownDescription: "Blueprint name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"description":   schema.StringAttribute{Optional: true, Description: "Blueprint description."},
+			"documentation": schema.StringAttribute{Optional: true, Description: "Blueprint documentation."},
+			"tasks": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Ordered list of tasks.",
+			},
+			"params": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Blueprint params (values are strings; typed per param definition).",
+			},
+			"profiles": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Profiles.",
+			},
+		},
+	}
+}
+
+func (r *blueprintResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type blueprintResourceModel struct {
+	Name          types.String `tfsdk:"name"`
+	Description   types.String `tfsdk:"description"`
+	Documentation types.String `tfsdk:"documentation"`
+	Tasks         types.List   `tfsdk:"tasks"`
+	Params        types.Map    `tfsdk:"params"`
+	Profiles      types.List   `tfsdk:"profiles"`
+}
+
+func (r *blueprintResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
+// ModifyPlan validates params against the schemas of their param definitions and checks
+// referenced tasks and profiles.
+func (r *blueprintResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan blueprintResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateParamMap(ctx, r.client, plan.Params, path.Root("params"), &resp.Diagnostics)
+	validateReferenceList(ctx, r.client, "tasks", plan.Tasks, path.Root("tasks"), &resp.Diagnostics)
+	validateReferenceList(ctx, r.client, "profiles", plan.Profiles, path.Root("profiles"), &resp.Diagnostics)
+}
+
+func (r *blueprintResource) expandBlueprint(ctx context.Context, m *blueprintResourceModel, diags *diag.Diagnostics) *models.Blueprint {
+	params := convertParamMap(ctx, r.client, m.Params, path.Root("params"), diags)
+	if diags.HasError() {
+		return nil
+	}
+	return &models.Blueprint{
+		Name:    m.Name.ValueString(),
+		DocData: newDocData(m.Description.ValueString(), m.Documentation.ValueString()),
+		ParamData: models.ParamData{
+			Params: params,
+		},
+		ProfileData: models.ProfileData{
+			Profiles: diagListToStrings(ctx, m.Profiles, diags),
+		},
+		Tasks: diagListToStrings(ctx, m.Tasks, diags),
+	}
+}
+
+func (r *blueprintResource) flattenBlueprint(ctx context.Context, b *models.Blueprint, m *blueprintResourceModel, diags *diag.Diagnostics) {
+	m.Name = types.StringValue(b.Name)
+	m.Description = mergeOptString(m.Description, b.Description)
+	m.Documentation = mergeOptString(m.Documentation, b.Documentation)
+	m.Tasks = mergeOptStringList(ctx, m.Tasks, b.Tasks, diags)
+	m.Profiles = mergeOptStringList(ctx, m.Profiles, b.Profiles, diags)
+
+	var sm map[string]string
+	if b.Params != nil {
+		var err error
+		sm, err = interfaceMapToStringMap(b.Params)
+		if err != nil {
+			diags.AddError("Invalid blueprint params", err.Error())
+			return
+		}
+		keepEquivalentParamStrings(m.Params, sm)
+	}
+	m.Params = mergeOptStringMap(ctx, m.Params, sm, diags)
+}
+
+func (r *blueprintResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan blueprintResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	bp := r.expandBlueprint(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.createModel(ctx, bp); err != nil {
+		resp.Diagnostics.AddError("Create blueprint failed", err.Error())
+		return
+	}
+	res, err := r.client.getModel(ctx, "blueprints", bp.Name)
+	if err != nil {
+		resp.Diagnostics.AddError("Read blueprint after create failed", err.Error())
+		return
+	}
+	r.flattenBlueprint(ctx, res.(*models.Blueprint), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *blueprintResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state blueprintResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	res, err := r.client.getModel(ctx, "blueprints", state.Name.ValueString())
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "Not Found") {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read blueprint failed", err.Error())
+		return
+	}
+	warnIfContentOwned(res, &resp.Diagnostics)
+	r.flattenBlueprint(ctx, res.(*models.Blueprint), &state, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *blueprintResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan blueprintResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	bp := r.expandBlueprint(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.putModel(ctx, bp); err != nil {
+		resp.Diagnostics.AddError("Update blueprint failed", err.Error())
+		return
+	}
+	res, err := r.client.getModel(ctx, "blueprints", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read blueprint after update failed", err.Error())
+		return
+	}
+	r.flattenBlueprint(ctx, res.(*models.Blueprint), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *blueprintResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state blueprintResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.deleteModel(ctx, "blueprints", state.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddError("Delete blueprint failed", err.Error())
+	}
+}
@@ -0,0 +1,63 @@
+package drpv4
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccBlueprintResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_blueprint" "test" {
+						name = "test"
+						description = "test"
+						tasks = ["noop"]
+						params = {
+							test = "test"
+						}
+					}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_blueprint.test", "name", "test"),
+					resource.TestCheckResourceAttr("drp_blueprint.test", "description", "test"),
+					resource.TestCheckResourceAttr("drp_blueprint.test", "tasks.#", "1"),
+					resource.TestCheckResourceAttr("drp_blueprint.test", "tasks.0", "noop"),
+					resource.TestCheckResourceAttr("drp_blueprint.test", "params.test", "test"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_blueprint" "test" {
+						name = "test"
+						description = "updated"
+						tasks = ["noop", "noop"]
+					}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_blueprint.test", "description", "updated"),
+					resource.TestCheckResourceAttr("drp_blueprint.test", "tasks.#", "2"),
+					resource.TestCheckNoResourceAttr("drp_blueprint.test", "params"),
+				),
+			},
+			{
+				// Values of undefined params are sent as JSON and must come back as written.
+				Config: `
+					resource "drp_blueprint" "test" {
+						name = "test"
+						tasks = ["noop"]
+						params = {
+							"tf-acc-obj"    = "{ \"a\": [1, 2] }"
+							"tf-acc-number" = "2.50"
+						}
+					}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_blueprint.test", "params.tf-acc-obj", "{ \"a\": [1, 2] }"),
+					resource.TestCheckResourceAttr("drp_blueprint.test", "params.tf-acc-number", "2.50"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,284 @@
+package drpv4
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*bootenvResource)(nil)
+var _ resource.ResourceWithImportState = (*bootenvResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*bootenvResource)(nil)
+
+type bootenvResource struct {
+	client *Config
+}
+
+func NewBootEnvResource() resource.Resource {
+	return &bootenvResource{}
+}
+
+func (r *bootenvResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_bootenv"
+}
+
+func (r *bootenvResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Manages a Digital Rebar boot environment.",
+		MarkdownDescription: "Manages a Digital Rebar boot environment.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "BootEnv name.",
+				MarkdownDescription: "BootEnv name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"description":   schema.StringAttribute{Optional: true, Description: "BootEnv description."},
+			"documentation": schema.StringAttribute{Optional: true, Description: "BootEnv documentation."},
+			"os_name":       schema.StringAttribute{Optional: true, Description: "OS name, e.g. centos-8."},
+			"os_family":     schema.StringAttribute{Optional: true, Description: "OS family, e.g. redhat."},
+			"os_codename":   schema.StringAttribute{Optional: true, Description: "OS codename."},
+			"os_version":    schema.StringAttribute{Optional: true, Description: "OS version."},
+			"iso_file":      schema.StringAttribute{Optional: true, Description: "Name of the install ISO."},
+			"iso_sha256":    schema.StringAttribute{Optional: true, Description: "SHA256 of the install ISO."},
+			"iso_url":       schema.StringAttribute{Optional: true, Description: "URL the install ISO can be downloaded from."},
+			"kernel":        schema.StringAttribute{Optional: true, Description: "Path of the kernel within the ISO."},
+			"initrds": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Paths of the initrds within the ISO.",
+			},
+			"boot_params": schema.StringAttribute{Optional: true, Description: "Kernel command line (may be templated)."},
+			"templates": schema.ListNestedAttribute{
+				Optional:     true,
+				NestedObject: schema.NestedAttributeObject{Attributes: taskTemplateNestedAttributes()},
+				Description:  "Templates rendered for machines in this bootenv.",
+			},
+			"required_params": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Required params.",
+			},
+			"optional_params": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Optional params.",
+			},
+			"only_unknown": schema.BoolAttribute{Optional: true, Description: "Only used for machines DRP does not know about (discovery)."},
+			"available": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether DRP considers the bootenv usable (e.g. its ISO has been uploaded).",
+			},
+			"errors": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Validation errors reported by DRP.",
+			},
+		},
+	}
+}
+
+func (r *bootenvResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type bootenvResourceModel struct {
+	Name           types.String `tfsdk:"name"`
+	Description    types.String `tfsdk:"description"`
+	Documentation  types.String `tfsdk:"documentation"`
+	OSName         types.String `tfsdk:"os_name"`
+	OSFamily       types.String `tfsdk:"os_family"`
+	OSCodename     types.String `tfsdk:"os_codename"`
+	OSVersion      types.String `tfsdk:"os_version"`
+	IsoFile        types.String `tfsdk:"iso_file"`
+	IsoSha256      types.String `tfsdk:"iso_sha256"`
+	IsoURL         types.String `tfsdk:"iso_url"`
+	Kernel         types.String `tfsdk:"kernel"`
+	Initrds        types.List   `tfsdk:"initrds"`
+	BootParams     types.String `tfsdk:"boot_params"`
+	Templates      types.List   `tfsdk:"templates"`
+	RequiredParams types.List   `tfsdk:"required_params"`
+	OptionalParams types.List   `tfsdk:"optional_params"`
+	OnlyUnknown    types.Bool   `tfsdk:"only_unknown"`
+	Available      types.Bool   `tfsdk:"available"`
+	Errors         types.List   `tfsdk:"errors"`
+}
+
+func (r *bootenvResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
+// ModifyPlan plans contents_sha256 of the inline templates.
+func (r *bootenvResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanTemplateHashes(ctx, req, resp, path.Root("templates"))
+}
+
+func (r *bootenvResource) expandBootEnv(ctx context.Context, m *bootenvResourceModel, diags *diag.Diagnostics) *models.BootEnv {
+	env := models.BootEnv{
+		Name:    m.Name.ValueString(),
+		DocData: newDocData(m.Description.ValueString(), m.Documentation.ValueString()),
+		OS: models.OsInfo{
+			Name:      m.OSName.ValueString(),
+			Family:    m.OSFamily.ValueString(),
+			Codename:  m.OSCodename.ValueString(),
+			Version:   m.OSVersion.ValueString(),
+			IsoFile:   m.IsoFile.ValueString(),
+			IsoSha256: m.IsoSha256.ValueString(),
+			IsoUrl:    m.IsoURL.ValueString(),
+		},
+		Kernel:         m.Kernel.ValueString(),
+		Initrds:        diagListToStrings(ctx, m.Initrds, diags),
+		BootParams:     m.BootParams.ValueString(),
+		RequiredParams: diagListToStrings(ctx, m.RequiredParams, diags),
+		OptionalParams: diagListToStrings(ctx, m.OptionalParams, diags),
+		OnlyUnknown:    m.OnlyUnknown.ValueBool(),
+	}
+	if diags.HasError() {
+		return nil
+	}
+	env.Templates = expandTaskTemplates(ctx, m.Templates, diags)
+	if diags.HasError() {
+		return nil
+	}
+	return &env
+}
+
+func (r *bootenvResource) flattenBootEnv(ctx context.Context, env *models.BootEnv, m *bootenvResourceModel, diags *diag.Diagnostics) {
+	m.Name = types.StringValue(env.Name)
+	m.Description = mergeOptString(m.Description, env.Description)
+	m.Documentation = mergeOptString(m.Documentation, env.Documentation)
+	m.OSName = mergeOptString(m.OSName, env.OS.Name)
+	m.OSFamily = mergeOptString(m.OSFamily, env.OS.Family)
+	m.OSCodename = mergeOptString(m.OSCodename, env.OS.Codename)
+	m.OSVersion = mergeOptString(m.OSVersion, env.OS.Version)
+	m.IsoFile = mergeOptString(m.IsoFile, env.OS.IsoFile)
+	m.IsoSha256 = mergeOptString(m.IsoSha256, env.OS.IsoSha256)
+	m.IsoURL = mergeOptString(m.IsoURL, env.OS.IsoUrl)
+	m.Kernel = mergeOptString(m.Kernel, env.Kernel)
+	m.Initrds = mergeOptStringList(ctx, m.Initrds, env.Initrds, diags)
+	m.BootParams = mergeOptString(m.BootParams, env.BootParams)
+	m.Templates = flattenTaskTemplatesMerged(ctx, m.Templates, env.Templates, diags)
+	m.RequiredParams = mergeOptStringList(ctx, m.RequiredParams, env.RequiredParams, diags)
+	m.OptionalParams = mergeOptStringList(ctx, m.OptionalParams, env.OptionalParams, diags)
+	m.OnlyUnknown = mergeOptBool(m.OnlyUnknown, env.OnlyUnknown)
+	m.Available = types.BoolValue(env.Available)
+	errs, d := types.ListValueFrom(ctx, types.StringType, append([]string{}, env.Errors...))
+	diags.Append(d...)
+	m.Errors = errs
+}
+
+// bootEnvValidationDiags turns the validation result DRP stores on the bootenv into
+// warnings, since a bootenv stays unavailable until its ISO has been uploaded.
+func bootEnvValidationDiags(env *models.BootEnv, diags *diag.Diagnostics) {
+	if env.Available {
+		return
+	}
+	detail := "DRP marked the bootenv as unavailable."
+	if len(env.Errors) > 0 {
+		detail = strings.Join(env.Errors, "\n")
+	}
+	diags.AddWarning("BootEnv "+env.Name+" is not available", detail)
+}
+
+func (r *bootenvResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan bootenvResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	env := r.expandBootEnv(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.createModel(ctx, env); err != nil {
+		resp.Diagnostics.AddError("Create bootenv failed", err.Error())
+		return
+	}
+	bo, err := r.client.getModel(ctx, "bootenvs", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read bootenv after create failed", err.Error())
+		return
+	}
+	got := bo.(*models.BootEnv)
+	bootEnvValidationDiags(got, &resp.Diagnostics)
+	r.flattenBootEnv(ctx, got, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *bootenvResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state bootenvResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	bo, err := r.client.getModel(ctx, "bootenvs", state.Name.ValueString())
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "Not Found") {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read bootenv failed", err.Error())
+		return
+	}
+	warnIfContentOwned(bo, &resp.Diagnostics)
+	r.flattenBootEnv(ctx, bo.(*models.BootEnv), &state, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *bootenvResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan bootenvResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	env := r.expandBootEnv(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.putModel(ctx, env); err != nil {
+		resp.Diagnostics.AddError("Update bootenv failed", err.Error())
+		return
+	}
+	bo, err := r.client.getModel(ctx, "bootenvs", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read bootenv after update failed", err.Error())
+		return
+	}
+	got := bo.(*models.BootEnv)
+	bootEnvValidationDiags(got, &resp.Diagnostics)
+	r.flattenBootEnv(ctx, got, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *bootenvResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state bootenvResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.deleteModel(ctx, "bootenvs", state.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddError("Delete bootenv failed", err.Error())
+	}
+}
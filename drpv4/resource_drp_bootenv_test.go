@@ -0,0 +1,65 @@
+package drpv4
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccBootEnvResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_bootenv" "test" {
+						name = "test-install"
+						description = "test"
+						os_name = "test-1"
+						os_family = "redhat"
+						iso_file = "test-1.iso"
+						iso_sha256 = "0000000000000000000000000000000000000000000000000000000000000000"
+						kernel = "images/pxeboot/vmlinuz"
+						initrds = ["images/pxeboot/initrd.img"]
+						boot_params = "ksdevice=bootif"
+						required_params = ["test"]
+
+						templates = [{
+							name = "test.ks"
+							contents = "install"
+							path = "{{.Machine.Path}}/test.ks"
+						}]
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_bootenv.test", "name", "test-install"),
+					resource.TestCheckResourceAttr("drp_bootenv.test", "os_name", "test-1"),
+					resource.TestCheckResourceAttr("drp_bootenv.test", "initrds.#", "1"),
+					resource.TestCheckResourceAttr("drp_bootenv.test", "templates.#", "1"),
+					resource.TestCheckResourceAttr("drp_bootenv.test", "available", "false"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_bootenv" "test" {
+						name = "test-install"
+						description = ""
+						os_name = "test-1"
+						os_family = "redhat"
+						iso_file = "test-1.iso"
+						kernel = "images/pxeboot/vmlinuz"
+						initrds = ["images/pxeboot/initrd.img"]
+						boot_params = "ksdevice=bootif console=ttyS0"
+						required_params = ["test"]
+						only_unknown = false
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_bootenv.test", "description", ""),
+					resource.TestCheckResourceAttr("drp_bootenv.test", "boot_params", "ksdevice=bootif console=ttyS0"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,433 @@
+package drpv4
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+	"gitlab.com/rackn/provision/v4/store"
+)
+
+var (
+	_ resource.Resource                     = (*contentResource)(nil)
+	_ resource.ResourceWithImportState      = (*contentResource)(nil)
+	_ resource.ResourceWithConfigValidators = (*contentResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*contentResource)(nil)
+)
+
+type contentResource struct {
+	client *Config
+}
+
+func NewContentResource() resource.Resource {
+	return &contentResource{}
+}
+
+func (r *contentResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_content"
+}
+
+func (r *contentResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("source_file"),
+			path.MatchRoot("content"),
+		),
+	}
+}
+
+func (r *contentResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Installs a content pack and upgrades it when the bundle changes.",
+		MarkdownDescription: "Installs a content pack and upgrades it when the bundle changes.",
+		Attributes: map[string]schema.Attribute{
+			"source_file": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Path to a content bundle in YAML or JSON (as produced by drpcli contents bundle).",
+				MarkdownDescription: "Path to a content bundle in YAML or JSON (as produced by `drpcli contents bundle`).",
+			},
+			"content": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Inline content bundle in YAML or JSON.",
+				MarkdownDescription: "Inline content bundle in YAML or JSON.",
+			},
+			"allow_downgrade": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Allow replacing the installed pack with a lower version.",
+				MarkdownDescription: "Allow replacing the installed pack with a lower version.",
+			},
+			"name": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Content pack name (meta.Name of the bundle).",
+				MarkdownDescription: "Content pack name (`meta.Name` of the bundle).",
+			},
+			"version": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Installed content pack version.",
+				MarkdownDescription: "Installed content pack version.",
+			},
+			"content_sha256": schema.StringAttribute{
+				Computed:            true,
+				Description:         "SHA256 of the bundle document, used to detect changes to source_file. Cleared on refresh when the installed pack no longer matches the bundle.",
+				MarkdownDescription: "SHA256 of the bundle document, used to detect changes to `source_file`. Cleared on refresh when the installed pack no longer matches the bundle.",
+			},
+			"objects": schema.MapAttribute{
+				ElementType:         types.ListType{ElemType: types.StringType},
+				Computed:            true,
+				Description:         "Keys of the objects the pack owns, by object type (e.g. tasks, stages).",
+				MarkdownDescription: "Keys of the objects the pack owns, by object type (e.g. `tasks`, `stages`).",
+			},
+		},
+	}
+}
+
+func (r *contentResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type contentResourceModel struct {
+	SourceFile     types.String `tfsdk:"source_file"`
+	Content        types.String `tfsdk:"content"`
+	AllowDowngrade types.Bool   `tfsdk:"allow_downgrade"`
+	Name           types.String `tfsdk:"name"`
+	Version        types.String `tfsdk:"version"`
+	ContentSha256  types.String `tfsdk:"content_sha256"`
+	Objects        types.Map    `tfsdk:"objects"`
+}
+
+func (r *contentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
+// loadContentDocument reads the bundle from source_file or content and returns it along
+// with the SHA256 of the raw document.
+func loadContentDocument(m *contentResourceModel) (*models.Content, string, error) {
+	var buf []byte
+	if f := m.SourceFile.ValueString(); f != "" {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to read %s: %w", f, err)
+		}
+		buf = b
+	} else {
+		buf = []byte(m.Content.ValueString())
+	}
+	doc := &models.Content{}
+	if err := store.YamlCodec.Decode(buf, doc); err != nil {
+		return nil, "", fmt.Errorf("unable to parse content bundle: %w", err)
+	}
+	if doc.Meta.Name == "" {
+		return nil, "", fmt.Errorf("content bundle has no meta.Name")
+	}
+	sum := sha256.Sum256(buf)
+	return doc, hex.EncodeToString(sum[:]), nil
+}
+
+func contentDocumentPath(m *contentResourceModel) path.Path {
+	if m.SourceFile.ValueString() != "" {
+		return path.Root("source_file")
+	}
+	return path.Root("content")
+}
+
+func contentObjects(ctx context.Context, sections models.Sections, diags *diag.Diagnostics) types.Map {
+	objs := make(map[string][]string, len(sections))
+	for prefix, section := range sections {
+		if len(section) == 0 {
+			continue
+		}
+		objs[prefix] = slices.Sorted(maps.Keys(section))
+	}
+	out, d := types.MapValueFrom(ctx, types.ListType{ElemType: types.StringType}, objs)
+	diags.Append(d...)
+	return out
+}
+
+// contentMatches reports whether the installed pack still holds what doc defines: the same
+// objects, each with the values doc sets. Fields the server adds are ignored, so a pack
+// re-uploaded at the same version with different objects or values does not match.
+func contentMatches(doc, installed *models.Content) bool {
+	sectionKeys := func(c *models.Content) map[string][]string {
+		out := map[string][]string{}
+		for prefix, section := range c.Sections {
+			if len(section) > 0 {
+				out[prefix] = slices.Sorted(maps.Keys(section))
+			}
+		}
+		return out
+	}
+	if !maps.EqualFunc(sectionKeys(doc), sectionKeys(installed), slices.Equal[[]string]) {
+		return false
+	}
+	want, err := contentJSONValue(doc)
+	if err != nil {
+		return false
+	}
+	got, err := contentJSONValue(installed)
+	if err != nil {
+		return false
+	}
+	return jsonSubset(want, got)
+}
+
+// contentJSONValue returns c as decoded JSON, without the read-only metadata the server sets.
+func contentJSONValue(c *models.Content) (map[string]interface{}, error) {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(buf, &out); err != nil {
+		return nil, err
+	}
+	if meta, ok := out["meta"].(map[string]interface{}); ok {
+		delete(meta, "Type")
+		delete(meta, "Writable")
+		delete(meta, "Overwritable")
+	}
+	return out, nil
+}
+
+// jsonSubset reports whether every value set in want is present and equal in got. Zero
+// values in want also match values missing from got.
+func jsonSubset(want, got interface{}) bool {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, _ := got.(map[string]interface{})
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok {
+				if isZeroJSON(wv) {
+					continue
+				}
+				return false
+			}
+			if !jsonSubset(wv, gv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		g, _ := got.([]interface{})
+		if len(w) != len(g) {
+			return false
+		}
+		for i := range w {
+			if !jsonSubset(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		if isZeroJSON(want) && isZeroJSON(got) {
+			return true
+		}
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+func isZeroJSON(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case bool:
+		return !t
+	case float64:
+		return t == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+// checkContentUpgrade rejects replacing the installed version with a lower one. Versions
+// that are not semver are not compared.
+func checkContentUpgrade(installed, next string, allowDowngrade bool) error {
+	if allowDowngrade || installed == "" || installed == next {
+		return nil
+	}
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return nil
+	}
+	to, err := semver.NewVersion(next)
+	if err != nil {
+		return nil
+	}
+	if to.LessThan(from) {
+		return fmt.Errorf("bundle version %s is lower than the installed version %s; set allow_downgrade to replace it", next, installed)
+	}
+	return nil
+}
+
+// ModifyPlan fills the computed attributes from the bundle so changes to the document show
+// up in the plan, and forces a new pack when the bundle name changes.
+func (r *contentResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan contentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.SourceFile.IsUnknown() || plan.Content.IsUnknown() {
+		return
+	}
+	doc, sum, err := loadContentDocument(&plan)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(contentDocumentPath(&plan), "Invalid content bundle", err.Error())
+		return
+	}
+	if !req.State.Raw.IsNull() {
+		var state contentResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		switch {
+		case state.Name.ValueString() != doc.Meta.Name:
+			resp.RequiresReplace = append(resp.RequiresReplace, path.Root("name"))
+		case state.ContentSha256.ValueString() == sum && state.Version.ValueString() == doc.Meta.Version:
+			plan.Name, plan.Version, plan.ContentSha256, plan.Objects = state.Name, state.Version, state.ContentSha256, state.Objects
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+			return
+		default:
+			if err := checkContentUpgrade(state.Version.ValueString(), doc.Meta.Version, plan.AllowDowngrade.ValueBool()); err != nil {
+				resp.Diagnostics.AddAttributeError(contentDocumentPath(&plan), "Content downgrade", err.Error())
+				return
+			}
+		}
+	}
+	plan.Name = types.StringValue(doc.Meta.Name)
+	plan.Version = types.StringValue(doc.Meta.Version)
+	plan.ContentSha256 = types.StringValue(sum)
+	plan.Objects = contentObjects(ctx, doc.Sections, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+// install uploads the bundle, creating the pack or replacing the installed one.
+func (r *contentResource) install(ctx context.Context, m *contentResourceModel, replace bool, diags *diag.Diagnostics) {
+	doc, sum, err := loadContentDocument(m)
+	if err != nil {
+		diags.AddAttributeError(contentDocumentPath(m), "Invalid content bundle", err.Error())
+		return
+	}
+	summary := &models.ContentSummary{}
+	err = r.client.do(ctx, func() *api.R {
+		if replace {
+			return r.client.session.Req().Put(doc).UrlFor("contents", doc.Meta.Name)
+		}
+		return r.client.session.Req().Post(doc).UrlFor("contents")
+	}, summary)
+	if err != nil {
+		diags.AddError("Install content failed", err.Error())
+		return
+	}
+	for _, w := range summary.Warnings {
+		diags.AddWarning("Content pack "+doc.Meta.Name, w)
+	}
+	m.Name = types.StringValue(doc.Meta.Name)
+	m.Version = types.StringValue(doc.Meta.Version)
+	m.ContentSha256 = types.StringValue(sum)
+	m.Objects = contentObjects(ctx, doc.Sections, diags)
+}
+
+func (r *contentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan contentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.install(ctx, &plan, false, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *contentResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state contentResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	name := state.Name.ValueString()
+	content := &models.Content{}
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().UrlFor("contents", name)
+	}, content); err != nil {
+		if strings.HasSuffix(err.Error(), "Not Found") {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read content failed", err.Error())
+		return
+	}
+	// A pack re-uploaded at the same version keeps its name and version; compare its objects
+	// with the bundle and clear content_sha256 so the difference is planned as an update.
+	if doc, _, err := loadContentDocument(&state); err == nil && !contentMatches(doc, content) {
+		tflog.Info(ctx, "installed content pack differs from the bundle", map[string]interface{}{"name": name, "version": content.Meta.Version})
+		state.ContentSha256 = types.StringNull()
+	}
+	state.Name = types.StringValue(content.Meta.Name)
+	state.Version = types.StringValue(content.Meta.Version)
+	state.Objects = contentObjects(ctx, content.Sections, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *contentResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan contentResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.install(ctx, &plan, true, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *contentResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state contentResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Del().UrlFor("contents", state.Name.ValueString())
+	}, nil); err != nil {
+		resp.Diagnostics.AddError("Delete content failed", err.Error())
+	}
+}
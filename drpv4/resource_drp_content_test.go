@@ -0,0 +1,148 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+	"gitlab.com/rackn/provision/v4/store"
+)
+
+func testAccContentConfig(name, version, task string) string {
+	return fmt.Sprintf(`
+		resource "drp_content" "test" {
+			content = <<-EOF
+			meta:
+			  Name: %[1]s
+			  Version: %[2]s
+			sections:
+			  tasks:
+			    %[3]s:
+			      Name: %[3]s
+			      Description: test
+			EOF
+		}
+	`, name, version, task)
+}
+
+func TestAccContentResource(t *testing.T) {
+	name := "tf-acc-" + accRandomSuffix(8)
+	var c *Config
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck: func() {
+			testAccPreCheck(t)
+			c = testAccClient(t)
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccContentConfig(name, "v1.0.0", name+"-task"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_content.test", "name", name),
+					resource.TestCheckResourceAttr("drp_content.test", "version", "v1.0.0"),
+					resource.TestCheckResourceAttr("drp_content.test", "objects.tasks.#", "1"),
+					resource.TestCheckResourceAttr("drp_content.test", "objects.tasks.0", name+"-task"),
+				),
+			},
+			{
+				Config: testAccContentConfig(name, "v1.1.0", name+"-task2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_content.test", "version", "v1.1.0"),
+					resource.TestCheckResourceAttr("drp_content.test", "objects.tasks.0", name+"-task2"),
+				),
+			},
+			{
+				// Re-upload the pack at the same version with a changed task; the next apply
+				// must put the bundle back.
+				PreConfig: func() {
+					doc := &models.Content{}
+					doc.Meta.Name = name
+					doc.Meta.Version = "v1.1.0"
+					doc.Sections = models.Sections{"tasks": models.Section{
+						name + "-task2": map[string]interface{}{"Name": name + "-task2", "Description": "changed"},
+					}}
+					if err := c.do(context.Background(), func() *api.R {
+						return c.session.Req().Put(doc).UrlFor("contents", name)
+					}, &models.ContentSummary{}); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccContentConfig(name, "v1.1.0", name+"-task2"),
+				Check: func(*terraform.State) error {
+					mo, err := c.getModel(context.Background(), "tasks", name+"-task2")
+					if err != nil {
+						return err
+					}
+					if d := mo.(*models.Task).Description; d != "test" {
+						return fmt.Errorf("task description = %q after apply, want %q", d, "test")
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestContentMatches(t *testing.T) {
+	const bundle = `
+meta:
+  Name: pack
+  Version: v1.0.0
+sections:
+  tasks:
+    t1:
+      Name: t1
+      Description: test
+      Templates:
+        - Name: run
+          Contents: echo run
+`
+	decode := func(doc string) *models.Content {
+		t.Helper()
+		c := &models.Content{}
+		if err := store.YamlCodec.Decode([]byte(doc), c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	installed := func(edit func(task map[string]interface{}, c *models.Content)) *models.Content {
+		c := decode(bundle)
+		c.Meta.Type = "dynamic"
+		task := c.Sections["tasks"]["t1"].(map[string]interface{})
+		task["Validated"] = true
+		task["Available"] = true
+		task["Errors"] = []interface{}{}
+		task["Bundle"] = "pack"
+		if edit != nil {
+			edit(task, c)
+		}
+		return c
+	}
+	cases := []struct {
+		name string
+		edit func(task map[string]interface{}, c *models.Content)
+		want bool
+	}{
+		{name: "as installed", want: true},
+		{name: "changed value", edit: func(task map[string]interface{}, _ *models.Content) { task["Description"] = "changed" }},
+		{name: "changed template", edit: func(task map[string]interface{}, _ *models.Content) {
+			task["Templates"] = []interface{}{map[string]interface{}{"Name": "run", "Contents": "echo changed"}}
+		}},
+		{name: "missing value", edit: func(task map[string]interface{}, _ *models.Content) { delete(task, "Description") }},
+		{name: "extra object", edit: func(_ map[string]interface{}, c *models.Content) {
+			c.Sections["stages"] = models.Section{"s1": map[string]interface{}{"Name": "s1"}}
+		}},
+		{name: "changed meta", edit: func(_ map[string]interface{}, c *models.Content) { c.Meta.Description = "changed" }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := contentMatches(decode(bundle), installed(tc.edit)); got != tc.want {
+				t.Errorf("contentMatches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -7,21 +7,33 @@ package drpv4
 import (
 	"context"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/jp"
 	"gitlab.com/rackn/provision/v4/models"
 )
 
 var _ resource.Resource = (*machineResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*machineResource)(nil)
+var _ resource.ResourceWithImportState = (*machineResource)(nil)
 
 type machineResource struct {
 	client *Config
@@ -35,6 +47,31 @@ func (r *machineResource) Metadata(_ context.Context, _ resource.MetadataRequest
 	resp.TypeName = "drp_machine"
 }
 
+// machineDetailResourceAttributes returns the machine detail attributes of the machine data
+// sources as computed resource attributes, leaving out those drp_machine defines itself.
+func machineDetailResourceAttributes() map[string]schema.Attribute {
+	out := map[string]schema.Attribute{}
+	for k, a := range machineDetailAttributes() {
+		switch k {
+		case "address", "pool", "pool_status":
+			continue
+		}
+		switch t := a.(type) {
+		case dsschema.StringAttribute:
+			out[k] = schema.StringAttribute{Computed: true, Description: t.Description, MarkdownDescription: t.MarkdownDescription}
+		case dsschema.BoolAttribute:
+			out[k] = schema.BoolAttribute{Computed: true, Description: t.Description, MarkdownDescription: t.MarkdownDescription}
+		case dsschema.Int64Attribute:
+			out[k] = schema.Int64Attribute{Computed: true, Description: t.Description, MarkdownDescription: t.MarkdownDescription}
+		case dsschema.ListAttribute:
+			out[k] = schema.ListAttribute{ElementType: t.ElementType, Computed: true, Description: t.Description, MarkdownDescription: t.MarkdownDescription}
+		case dsschema.MapAttribute:
+			out[k] = schema.MapAttribute{ElementType: t.ElementType, Computed: true, Description: t.Description, MarkdownDescription: t.MarkdownDescription}
+		}
+	}
+	return out
+}
+
 func (r *machineResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
@@ -63,23 +100,36 @@ func (r *machineResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				Description:         "Max time string to wait for pool operations.",
 				MarkdownDescription: "Max time string to wait for pool operations.",
 			},
+			"wait_for_capacity": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "When the pool has no free machine, keep retrying allocation as machines free up instead of failing.",
+				MarkdownDescription: "When the pool has no free machine, keep retrying allocation as machines free up instead of failing.",
+			},
+			"capacity_timeout": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("30m"),
+				Description:         "Max time string to wait for a free machine with wait_for_capacity.",
+				MarkdownDescription: "Max time string to wait for a free machine with `wait_for_capacity`.",
+			},
 			"add_profiles": schema.ListAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
 				Description:         "Profiles to add to Machine.Profiles (must already exist).",
 				MarkdownDescription: "Profiles to add to Machine.Profiles (must already exist).",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.RequiresReplace(),
-				},
 			},
 			"add_parameters": schema.ListAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
-				Description:         "Parameters (key: value) to add to Machine.Params.",
-				MarkdownDescription: "Parameters (key: value) to add to Machine.Params.",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.RequiresReplace(),
-				},
+				Description:         "Parameters (key: value) to add to Machine.Params. Values are always sent as strings.",
+				MarkdownDescription: "Parameters (key: value) to add to Machine.Params. Values are always sent as strings.",
+				DeprecationMessage:  "Use parameters instead; it types values per param definition.",
+			},
+			"parameters": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Parameters to add to Machine.Params (values are strings; typed per param definition). Takes precedence over add_parameters.",
+				MarkdownDescription: "Parameters to add to Machine.Params (values are strings; typed per param definition). Takes precedence over `add_parameters`.",
 			},
 			"filters": schema.ListAttribute{
 				ElementType:         types.StringType,
@@ -96,6 +146,73 @@ func (r *machineResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 				Description:         "Sets access-keys param on the machine requested.",
 				MarkdownDescription: "Sets access-keys param on the machine requested.",
 			},
+			"wait_for_workflow_complete": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Wait after allocation until Machine.WorkflowComplete is true.",
+				MarkdownDescription: "Wait after allocation until Machine.WorkflowComplete is true.",
+			},
+			"target_stage": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Wait after allocation until the machine reaches this stage.",
+				MarkdownDescription: "Wait after allocation until the machine reaches this stage.",
+			},
+			"wait_timeout": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("60m"),
+				Description:         "Max time string to wait for the workflow or target stage.",
+				MarkdownDescription: "Max time string to wait for the workflow or target stage.",
+			},
+			"work_order_mode": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Set Machine.WorkOrderMode so the machine runs work orders instead of its workflow. Cleared again before the machine is released.",
+				MarkdownDescription: "Set `Machine.WorkOrderMode` so the machine runs work orders instead of its workflow. Cleared again before the machine is released.",
+			},
+			"power_on_create": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Power the machine on through the IPMI plugin after allocation.",
+				MarkdownDescription: "Power the machine on through the IPMI plugin after allocation.",
+			},
+			"power_off_destroy": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Power the machine off through the IPMI plugin after it is released.",
+				MarkdownDescription: "Power the machine off through the IPMI plugin after it is released.",
+			},
+			"reboot_on_change": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "Set the next boot to PXE and power cycle the machine through the IPMI plugin when an update changes it.",
+				MarkdownDescription: "Set the next boot to PXE and power cycle the machine through the IPMI plugin when an update changes it.",
+			},
+			"release": schema.SingleNestedAttribute{
+				Optional:            true,
+				Description:         "Controls how the machine is returned to the pool on destroy.",
+				MarkdownDescription: "Controls how the machine is returned to the pool on destroy.",
+				Attributes: map[string]schema.Attribute{
+					"workflow": schema.StringAttribute{
+						Optional:            true,
+						Description:         "Workflow to run on the machine as it is released (for example a disk wipe).",
+						MarkdownDescription: "Workflow to run on the machine as it is released (for example a disk wipe).",
+					},
+					"wait_for_release_workflow": schema.BoolAttribute{
+						Optional:            true,
+						Description:         "Wait up to wait_timeout for the release workflow to complete; destroy fails if it does not.",
+						MarkdownDescription: "Wait up to `wait_timeout` for the release workflow to complete; destroy fails if it does not.",
+						Validators: []validator.Bool{
+							boolvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("workflow")),
+						},
+					},
+					"force": schema.BoolAttribute{
+						Optional:            true,
+						Description:         "Fail the machine's running job before releasing it, for machines stuck mid-workflow.",
+						MarkdownDescription: "Fail the machine's running job before releasing it, for machines stuck mid-workflow.",
+					},
+					"keep_on_destroy": schema.BoolAttribute{
+						Optional:            true,
+						Description:         "Drop the machine from state on destroy without releasing it.",
+						MarkdownDescription: "Drop the machine from state on destroy without releasing it.",
+					},
+				},
+			},
 			"address": schema.StringAttribute{
 				Computed:            true,
 				Description:         "Digital Rebar Machine.Address.",
@@ -113,6 +230,7 @@ func (r *machineResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 			},
 		},
 	}
+	maps.Copy(resp.Schema.Attributes, machineDetailResourceAttributes())
 }
 
 func (r *machineResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -120,16 +238,117 @@ func (r *machineResource) Configure(_ context.Context, req resource.ConfigureReq
 }
 
 type machineResourceModel struct {
-	ID             types.String `tfsdk:"id"`
-	Pool           types.String `tfsdk:"pool"`
-	Timeout        types.String `tfsdk:"timeout"`
-	AddProfiles    types.List   `tfsdk:"add_profiles"`
-	AddParameters  types.List   `tfsdk:"add_parameters"`
-	Filters        types.List   `tfsdk:"filters"`
-	AuthorizedKeys types.List   `tfsdk:"authorized_keys"`
-	Address        types.String `tfsdk:"address"`
-	Status         types.String `tfsdk:"status"`
-	Name           types.String `tfsdk:"name"`
+	ID              types.String `tfsdk:"id"`
+	Pool            types.String `tfsdk:"pool"`
+	Timeout         types.String `tfsdk:"timeout"`
+	WaitCapacity    types.Bool   `tfsdk:"wait_for_capacity"`
+	CapacityTimeout types.String `tfsdk:"capacity_timeout"`
+	AddProfiles     types.List   `tfsdk:"add_profiles"`
+	AddParameters   types.List   `tfsdk:"add_parameters"`
+	Parameters      types.Map    `tfsdk:"parameters"`
+	Filters         types.List   `tfsdk:"filters"`
+	AuthorizedKeys  types.List   `tfsdk:"authorized_keys"`
+	WaitWorkflow    types.Bool   `tfsdk:"wait_for_workflow_complete"`
+	TargetStage     types.String `tfsdk:"target_stage"`
+	WaitTimeout     types.String `tfsdk:"wait_timeout"`
+	WorkOrderMode   types.Bool   `tfsdk:"work_order_mode"`
+	PowerOnCreate   types.Bool   `tfsdk:"power_on_create"`
+	PowerOffDestroy types.Bool   `tfsdk:"power_off_destroy"`
+	RebootOnChange  types.Bool   `tfsdk:"reboot_on_change"`
+	Release         types.Object `tfsdk:"release"`
+	Address         types.String `tfsdk:"address"`
+	Status          types.String `tfsdk:"status"`
+	Name            types.String `tfsdk:"name"`
+
+	Workflow         types.String `tfsdk:"workflow"`
+	Stage            types.String `tfsdk:"stage"`
+	BootEnv          types.String `tfsdk:"bootenv"`
+	CurrentTask      types.Int64  `tfsdk:"current_task"`
+	Runnable         types.Bool   `tfsdk:"runnable"`
+	WorkflowComplete types.Bool   `tfsdk:"workflow_complete"`
+	Arch             types.String `tfsdk:"arch"`
+	HardwareAddrs    types.List   `tfsdk:"hardware_addrs"`
+	IPAddresses      types.List   `tfsdk:"ip_addresses"`
+	Profiles         types.List   `tfsdk:"profiles"`
+	Params           types.Map    `tfsdk:"params"`
+	Meta             types.Map    `tfsdk:"meta"`
+}
+
+// ImportState adopts an allocated machine by UUID or name:<machine-name>. All of its
+// profiles become add_profiles and the terraform-N access keys become authorized_keys.
+func (r *machineResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Import machine failed", "provider is not configured")
+		return
+	}
+	m, err := importMachine(ctx, r.client, req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Import machine failed", err.Error())
+		return
+	}
+	state := machineResourceModel{
+		ID:              types.StringValue(m.Uuid.String()),
+		Pool:            types.StringValue(m.Pool),
+		Timeout:         types.StringValue("5m"),
+		WaitCapacity:    types.BoolNull(),
+		CapacityTimeout: types.StringValue("30m"),
+		AddProfiles:     importedStringList(ctx, m.Profiles, &resp.Diagnostics),
+		AddParameters:   types.ListNull(types.StringType),
+		Parameters:      types.MapNull(types.StringType),
+		Filters:         types.ListNull(types.StringType),
+		AuthorizedKeys:  importedStringList(ctx, machineAuthorizedKeys(m), &resp.Diagnostics),
+		WaitWorkflow:    types.BoolNull(),
+		TargetStage:     types.StringNull(),
+		WaitTimeout:     types.StringValue("60m"),
+		WorkOrderMode:   types.BoolNull(),
+		PowerOnCreate:   types.BoolNull(),
+		PowerOffDestroy: types.BoolNull(),
+		RebootOnChange:  types.BoolNull(),
+		Release:         types.ObjectNull(machineReleaseAttrTypes()),
+		Address:         types.StringValue(m.Address.String()),
+		Status:          types.StringValue(string(m.PoolStatus)),
+		Name:            types.StringValue(m.Name),
+
+		Workflow:         types.StringNull(),
+		Stage:            types.StringNull(),
+		BootEnv:          types.StringNull(),
+		CurrentTask:      types.Int64Null(),
+		Runnable:         types.BoolNull(),
+		WorkflowComplete: types.BoolNull(),
+		Arch:             types.StringNull(),
+		HardwareAddrs:    types.ListNull(types.StringType),
+		IPAddresses:      types.ListNull(types.StringType),
+		Profiles:         types.ListNull(types.StringType),
+		Params:           types.MapNull(types.StringType),
+		Meta:             types.MapNull(types.StringType),
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func machineReleaseAttrTypes() map[string]attr.Type {
+	return map[string]attr.Type{
+		"workflow":                  types.StringType,
+		"wait_for_release_workflow": types.BoolType,
+		"force":                     types.BoolType,
+		"keep_on_destroy":           types.BoolType,
+	}
+}
+
+// ModifyPlan validates parameters against the schemas of their param definitions and checks
+// the release workflow.
+func (r *machineResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var params types.Map
+	var release types.Object
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("parameters"), &params)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("release"), &release)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateParamMap(ctx, r.client, params, path.Root("parameters"), &resp.Diagnostics)
+	validateReference(ctx, r.client, "workflows", priorObjString(release, "workflow"), path.Root("release").AtName("workflow"), &resp.Diagnostics)
 }
 
 func (r *machineResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -146,6 +365,27 @@ func (r *machineResource) Create(ctx context.Context, req resource.CreateRequest
 	if pool == "" {
 		pool = "default"
 	}
+	waitOpts := machineWaitOptions{
+		WorkflowComplete: plan.WaitWorkflow.ValueBool(),
+		TargetStage:      plan.TargetStage.ValueString(),
+	}
+	if waitOpts.enabled() {
+		d, err := time.ParseDuration(plan.WaitTimeout.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("wait_timeout"), "Invalid wait_timeout", err.Error())
+			return
+		}
+		waitOpts.Timeout = d
+	}
+	var capacityTimeout time.Duration
+	if plan.WaitCapacity.ValueBool() {
+		d, err := time.ParseDuration(plan.CapacityTimeout.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("capacity_timeout"), "Invalid capacity_timeout", err.Error())
+			return
+		}
+		capacityTimeout = d
+	}
 	timeout := plan.Timeout.ValueString()
 	parms := map[string]interface{}{
 		"pool/wait-timeout": timeout,
@@ -181,22 +421,9 @@ func (r *machineResource) Create(ctx context.Context, req resource.CreateRequest
 			parameters["access-keys"] = accesskeys
 		}
 	}
-	if !plan.AddParameters.IsNull() && !plan.AddParameters.IsUnknown() {
-		aparams, d := diagListToStringSlice(ctx, plan.AddParameters)
-		resp.Diagnostics.Append(d...)
-		if resp.Diagnostics.HasError() {
-			return
-		}
-		for _, p := range aparams {
-			param := strings.SplitN(p, ":", 2)
-			if len(param) < 2 {
-				resp.Diagnostics.AddError("Invalid add_parameters entry", fmt.Sprintf("expected key:value, got %q", p))
-				return
-			}
-			key := param[0]
-			value := strings.TrimLeft(param[1], " ")
-			parameters[key] = value
-		}
+	maps.Copy(parameters, r.expandMachineParameters(ctx, &plan, &resp.Diagnostics))
+	if resp.Diagnostics.HasError() {
+		return
 	}
 	if len(parameters) > 0 {
 		parms["pool/add-parameters"] = parameters
@@ -217,23 +444,99 @@ func (r *machineResource) Create(ctx context.Context, req resource.CreateRequest
 		}
 	}
 
-	pr := []*models.PoolResult{}
-	reqAPI := r.client.session.Req().Post(parms).UrlFor("pools", pool, "allocateMachines")
-	if err := reqAPI.Do(&pr); err != nil {
-		tflog.Debug(ctx, "allocateMachines failed", map[string]interface{}{"error": err.Error()})
+	var pr []*models.PoolResult
+	var err error
+	if plan.WaitCapacity.ValueBool() {
+		pr, err = allocatePoolMachinesWait(ctx, r.client, pool, parms, capacityTimeout)
+	} else {
+		pr, err = allocatePoolMachines(ctx, r.client, pool, parms)
+	}
+	if err != nil {
 		resp.Diagnostics.AddError("Allocation failed", fmt.Sprintf("Error allocating from pool %s: %s", pool, err))
 		return
 	}
+	if len(pr) == 0 {
+		resp.Diagnostics.AddError("Allocation failed", fmt.Sprintf("Pool %s returned no machines", pool))
+		return
+	}
+	if len(pr) > 1 {
+		r.releaseExtraMachines(ctx, pool, parms, pr[1:], &resp.Diagnostics)
+	}
 	mc := pr[0]
 	tflog.Debug(ctx, "allocated machine", map[string]interface{}{"status": mc.Status, "name": mc.Name, "uuid": mc.Uuid})
 
 	plan.ID = types.StringValue(mc.Uuid)
 	plan.Status = types.StringValue(string(mc.Status))
 	plan.Name = types.StringValue(mc.Name)
+	if !plan.WorkOrderMode.IsNull() && !plan.WorkOrderMode.IsUnknown() {
+		if err := r.setWorkOrderMode(ctx, mc.Uuid, plan.WorkOrderMode.ValueBool()); err != nil {
+			resp.Diagnostics.AddError("Set work order mode failed", fmt.Sprintf("machine %s: %s", mc.Uuid, err))
+		}
+	}
+	if plan.PowerOnCreate.ValueBool() && !resp.Diagnostics.HasError() {
+		runMachineActions(ctx, r.client, mc.Uuid, false, &resp.Diagnostics, "powerOn")
+	}
+	if waitOpts.enabled() && !resp.Diagnostics.HasError() {
+		// Keep the machine in state even when the wait fails so it is tainted rather than leaked.
+		if _, err := waitForMachine(ctx, r.client, mc.Uuid, waitOpts); err != nil {
+			resp.Diagnostics.AddError("Machine provisioning failed", err.Error())
+		}
+	}
 	r.machineReadIntoModel(ctx, mc.Uuid, &plan, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
+func (r *machineResource) setWorkOrderMode(ctx context.Context, uuid string, mode bool) error {
+	var patcher jp.Patcher
+	patcher.Replace(jp.Ptr("/WorkOrderMode"), mode)
+	patch, err := patcher.Patch()
+	if err != nil {
+		return fmt.Errorf("build patch: %w", err)
+	}
+	_, err = r.client.patchModel(ctx, "machines", uuid, patch)
+	return err
+}
+
+// failCurrentJob marks the machine's current job failed when it is still created or running,
+// so a machine stuck mid-workflow can be released.
+func (r *machineResource) failCurrentJob(ctx context.Context, m *models.Machine) error {
+	if m.CurrentJob == nil || (m.JobState != "created" && m.JobState != "running") {
+		return nil
+	}
+	var patcher jp.Patcher
+	patcher.Replace(jp.Ptr("/State"), "failed")
+	patch, err := patcher.Patch()
+	if err != nil {
+		return fmt.Errorf("build patch: %w", err)
+	}
+	tflog.Info(ctx, "failing current job before release", map[string]interface{}{"uuid": m.UUID(), "job": m.CurrentJob.String()})
+	_, err = r.client.patchModel(ctx, "jobs", m.CurrentJob.String(), patch)
+	return err
+}
+
+// releaseExtraMachines returns machines the pool allocated beyond the one this resource
+// tracks, undoing the profiles and parameters the allocation added to them.
+func (r *machineResource) releaseExtraMachines(ctx context.Context, pool string, allocParms map[string]interface{}, extra []*models.PoolResult, diags *diag.Diagnostics) {
+	uuids := poolResultUUIDs(extra)
+	tflog.Warn(ctx, "pool allocated more machines than requested, releasing extras", map[string]interface{}{"pool": pool, "machines": uuids})
+	parms := map[string]interface{}{
+		"pool/wait-timeout": allocParms["pool/wait-timeout"],
+		"pool/machine-list": uuids,
+	}
+	if profiles, ok := allocParms["pool/add-profiles"]; ok {
+		parms["pool/remove-profiles"] = profiles
+	}
+	if params, ok := allocParms["pool/add-parameters"].(map[string]interface{}); ok {
+		parms["pool/remove-parameters"] = slices.Sorted(maps.Keys(params))
+	}
+	if _, err := releasePoolMachines(ctx, r.client, pool, parms); err != nil {
+		diags.AddWarning(
+			"Release of extra machines failed",
+			fmt.Sprintf("Pool %s allocated extra machines %s that could not be released: %s", pool, strings.Join(uuids, ", "), err),
+		)
+	}
+}
+
 func (r *machineResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	if r.client == nil {
 		return
@@ -251,7 +554,7 @@ func (r *machineResource) machineReadIntoModel(ctx context.Context, uuid string,
 	if uuid == "" {
 		return
 	}
-	mo, err := r.client.session.GetModel("machines", uuid)
+	mo, err := r.client.getModel(ctx, "machines", uuid)
 	if err != nil {
 		if isNotFound(err) {
 			m.ID = types.StringNull()
@@ -264,19 +567,196 @@ func (r *machineResource) machineReadIntoModel(ctx context.Context, uuid string,
 	m.Status = types.StringValue(string(machineObject.PoolStatus))
 	m.Address = types.StringValue(machineObject.Address.String())
 	m.Name = types.StringValue(machineObject.Name)
+
+	var detail machineDetailModel
+	flattenMachineDetail(ctx, machineObject, &detail, diags)
+	m.Workflow = detail.Workflow
+	m.Stage = detail.Stage
+	m.BootEnv = detail.BootEnv
+	m.CurrentTask = detail.CurrentTask
+	m.Runnable = detail.Runnable
+	m.WorkflowComplete = detail.WorkflowComplete
+	m.Arch = detail.Arch
+	m.HardwareAddrs = detail.HardwareAddrs
+	m.IPAddresses = detail.IPAddresses
+	m.Profiles = detail.Profiles
+	m.Params = detail.Params
+	m.Meta = detail.Meta
+	if !m.WorkOrderMode.IsNull() {
+		m.WorkOrderMode = types.BoolValue(machineObject.WorkOrderMode)
+	}
+}
+
+// expandMachineParameters merges add_parameters (string values) and parameters (typed
+// per param definition) into the values to set on Machine.Params.
+func (r *machineResource) expandMachineParameters(ctx context.Context, m *machineResourceModel, diags *diag.Diagnostics) map[string]interface{} {
+	out := map[string]interface{}{}
+	entries, err := parseAddParameters(diagListToStrings(ctx, m.AddParameters, diags))
+	if err != nil {
+		diags.AddAttributeError(path.Root("add_parameters"), "Invalid add_parameters entry", err.Error())
+		return nil
+	}
+	for k, v := range entries {
+		out[k] = v
+	}
+	maps.Copy(out, convertParamMap(ctx, r.client, m.Parameters, path.Root("parameters"), diags))
+	return out
+}
+
+// machineParameterNames returns the param names added through add_parameters and parameters.
+func machineParameterNames(ctx context.Context, m *machineResourceModel, diags *diag.Diagnostics) []string {
+	entries, err := parseAddParameters(diagListToStrings(ctx, m.AddParameters, diags))
+	if err != nil {
+		diags.AddAttributeError(path.Root("add_parameters"), "Invalid add_parameters entry", err.Error())
+		return nil
+	}
+	if !m.Parameters.IsNull() && !m.Parameters.IsUnknown() {
+		for k := range m.Parameters.Elements() {
+			entries[k] = ""
+		}
+	}
+	return slices.Sorted(maps.Keys(entries))
+}
+
+// parseAddParameters splits add_parameters entries on the first colon into key/value pairs.
+func parseAddParameters(entries []string) (map[string]string, error) {
+	out := make(map[string]string, len(entries))
+	for _, p := range entries {
+		param := strings.SplitN(p, ":", 2)
+		if len(param) < 2 {
+			return nil, fmt.Errorf("expected key:value, got %q", p)
+		}
+		out[param[0]] = strings.TrimLeft(param[1], " ")
+	}
+	return out, nil
 }
 
 func (r *machineResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	if r.client == nil {
 		return
 	}
-	var state machineResourceModel
+	var plan, state machineResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	r.machineReadIntoModel(ctx, state.ID.ValueString(), &state, &resp.Diagnostics)
-	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	uuid := state.ID.ValueString()
+	plan.ID = state.ID
+
+	patch, changed := r.machineUpdatePatch(ctx, uuid, &state, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if changed {
+		if _, err := r.client.patchModel(ctx, "machines", uuid, patch); err != nil {
+			resp.Diagnostics.AddError("Update machine failed", fmt.Sprintf("unable to patch machine %s: %s", uuid, err))
+			return
+		}
+		if plan.RebootOnChange.ValueBool() {
+			runMachineActions(ctx, r.client, uuid, false, &resp.Diagnostics, "nextbootpxe", "powerOff", "powerOn")
+		}
+	}
+	r.machineReadIntoModel(ctx, uuid, &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// machineUpdatePatch builds a JSON patch moving the machine from the profiles, parameters,
+// work order mode and authorized keys in state to those in plan. Profiles and params that
+// Terraform did not add are left untouched, as are access-keys entries not named terraform-N.
+func (r *machineResource) machineUpdatePatch(ctx context.Context, uuid string, state, plan *machineResourceModel, diags *diag.Diagnostics) (jp.Patch, bool) {
+	mo, err := r.client.getModel(ctx, "machines", uuid)
+	if err != nil {
+		diags.AddError("Update machine failed", fmt.Sprintf("unable to get machine %s: %s", uuid, err))
+		return nil, false
+	}
+	machine := mo.(*models.Machine)
+	var patcher jp.Patcher
+	changed := false
+
+	oldProfiles := diagListToStrings(ctx, state.AddProfiles, diags)
+	newProfiles := diagListToStrings(ctx, plan.AddProfiles, diags)
+	profiles := []string{}
+	for _, p := range machine.Profiles {
+		if slices.Contains(oldProfiles, p) && !slices.Contains(newProfiles, p) {
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	for _, p := range newProfiles {
+		if !slices.Contains(profiles, p) {
+			profiles = append(profiles, p)
+		}
+	}
+	if !slices.Equal(profiles, machine.Profiles) {
+		patcher.Replace(jp.Ptr("/Profiles"), profiles)
+		changed = true
+	}
+
+	oldParams := r.expandMachineParameters(ctx, state, diags)
+	newParams := r.expandMachineParameters(ctx, plan, diags)
+	if diags.HasError() {
+		return nil, false
+	}
+	params := machine.Params
+	if params == nil {
+		params = map[string]interface{}{}
+		patcher.Add(jp.Ptr("/Params"), params)
+	}
+	for _, k := range slices.Sorted(maps.Keys(oldParams)) {
+		if _, keep := newParams[k]; keep {
+			continue
+		}
+		if _, ok := params[k]; ok {
+			patcher.Remove(jp.PtrTo("Params", k))
+			changed = true
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(newParams)) {
+		if v, ok := oldParams[k]; ok && reflect.DeepEqual(v, newParams[k]) {
+			continue
+		}
+		patcher.Add(jp.PtrTo("Params", k), newParams[k])
+		changed = true
+	}
+
+	if !plan.WorkOrderMode.IsNull() && !plan.WorkOrderMode.IsUnknown() && plan.WorkOrderMode.ValueBool() != machine.WorkOrderMode {
+		patcher.Replace(jp.Ptr("/WorkOrderMode"), plan.WorkOrderMode.ValueBool())
+		changed = true
+	}
+
+	oldKeys := diagListToStrings(ctx, state.AuthorizedKeys, diags)
+	newKeys := diagListToStrings(ctx, plan.AuthorizedKeys, diags)
+	if !slices.Equal(oldKeys, newKeys) {
+		accessKeys := map[string]interface{}{}
+		if cur, ok := params["access-keys"].(map[string]interface{}); ok {
+			for k, v := range cur {
+				if !strings.HasPrefix(k, "terraform-") {
+					accessKeys[k] = v
+				}
+			}
+		}
+		for i, k := range newKeys {
+			accessKeys[fmt.Sprintf("terraform-%d", i)] = k
+		}
+		if len(accessKeys) > 0 {
+			patcher.Add(jp.PtrTo("Params", "access-keys"), accessKeys)
+			changed = true
+		} else if _, ok := params["access-keys"]; ok {
+			patcher.Remove(jp.PtrTo("Params", "access-keys"))
+			changed = true
+		}
+	}
+	if diags.HasError() || !changed {
+		return nil, false
+	}
+
+	patch, err := patcher.Patch()
+	if err != nil {
+		diags.AddError("Update machine failed", fmt.Sprintf("build patch: %s", err))
+		return nil, false
+	}
+	return patch, true
 }
 
 func (r *machineResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -293,20 +773,17 @@ func (r *machineResource) Delete(ctx context.Context, req resource.DeleteRequest
 	if uuid == "" {
 		return
 	}
+	if priorObjBool(state.Release, "keep_on_destroy").ValueBool() {
+		tflog.Info(ctx, "keeping machine on destroy", map[string]interface{}{"uuid": uuid})
+		return
+	}
 	pool := state.Pool.ValueString()
 	if pool == "" {
 		resp.Diagnostics.AddError("Delete machine failed", "pool is required to release the machine")
 		return
 	}
 
-	var readState machineResourceModel
-	readState.ID = state.ID
-	readState.Pool = state.Pool
-	readState.Timeout = state.Timeout
-	readState.AddProfiles = state.AddProfiles
-	readState.AddParameters = state.AddParameters
-	readState.Filters = state.Filters
-	readState.AuthorizedKeys = state.AuthorizedKeys
+	readState := state
 	r.machineReadIntoModel(ctx, uuid, &readState, &resp.Diagnostics)
 	if resp.Diagnostics.HasError() {
 		return
@@ -315,7 +792,6 @@ func (r *machineResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	pr := []*models.PoolResult{}
 	parms := map[string]interface{}{
 		"pool/wait-timeout": state.Timeout.ValueString(),
 		"pool/machine-list": []string{uuid},
@@ -339,29 +815,79 @@ func (r *machineResource) Delete(ctx context.Context, req resource.DeleteRequest
 			parameters = append(parameters, "access-keys")
 		}
 	}
-	if !state.AddParameters.IsNull() && !state.AddParameters.IsUnknown() {
-		aparams, d := diagListToStringSlice(ctx, state.AddParameters)
-		resp.Diagnostics.Append(d...)
-		for _, p := range aparams {
-			param := strings.SplitN(p, ":", 2)
-			if len(param) < 2 {
-				resp.Diagnostics.AddError("Invalid add_parameters entry", fmt.Sprintf("expected key:value, got %q", p))
-				return
-			}
-			parameters = append(parameters, param[0])
-		}
+	parameters = append(parameters, machineParameterNames(ctx, &state, &resp.Diagnostics)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 	if len(parameters) > 0 {
 		parms["pool/remove-parameters"] = parameters
 	}
+	workflow := priorObjString(state.Release, "workflow").ValueString()
+	if workflow != "" {
+		parms["pool/workflow"] = workflow
+	}
+	waitRelease := workflow != "" && priorObjBool(state.Release, "wait_for_release_workflow").ValueBool()
+	var waitTimeout time.Duration
+	if waitRelease {
+		d, err := time.ParseDuration(state.WaitTimeout.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("wait_timeout"), "Invalid wait_timeout", err.Error())
+			return
+		}
+		waitTimeout = d
+	}
+
+	// Hand the machine back running workflows, as it was before work_order_mode set it.
+	if state.WorkOrderMode.ValueBool() {
+		if err := r.setWorkOrderMode(ctx, uuid, false); err != nil {
+			resp.Diagnostics.AddError("Release failed", fmt.Sprintf("unable to clear work order mode of %s: %s", uuid, err))
+			return
+		}
+	}
+	// The job running before the release, failed below when forcing, is not part of the
+	// release workflow, so its failure must not end the wait for it.
+	force := priorObjBool(state.Release, "force").ValueBool()
+	var priorJob string
+	if force || waitRelease {
+		mo, err := r.client.getModel(ctx, "machines", uuid)
+		if err != nil {
+			resp.Diagnostics.AddError("Release failed", fmt.Sprintf("unable to get machine %s: %s", uuid, err))
+			return
+		}
+		m := mo.(*models.Machine)
+		priorJob = m.CurrentJob.String()
+		if force {
+			if err := r.failCurrentJob(ctx, m); err != nil {
+				resp.Diagnostics.AddError("Release failed", fmt.Sprintf("unable to stop the running job of %s: %s", uuid, err))
+				return
+			}
+		}
+	}
 
-	reqAPI := r.client.session.Req().Post(parms).UrlFor("pools", pool, "releaseMachines")
-	if err := reqAPI.Do(&pr); err != nil {
+	pr, err := releasePoolMachines(ctx, r.client, pool, parms)
+	if err != nil {
 		resp.Diagnostics.AddError("Release failed", fmt.Sprintf("Error releasing %s from pool %s: %s", uuid, pool, err))
 		return
 	}
-	mc := pr[0]
-	if mc.Status != "Free" {
+	// A release workflow keeps the machine out of Free until it completes.
+	if len(pr) == 0 || (workflow == "" && pr[0].Status != "Free") {
 		resp.Diagnostics.AddError("Release failed", fmt.Sprintf("Could not release %s from pool %s", uuid, pool))
+		return
+	}
+	if waitRelease {
+		opts := machineWaitOptions{WorkflowComplete: true, Timeout: waitTimeout, IgnoreJob: priorJob}
+		if _, err := waitForMachine(ctx, r.client, uuid, opts); err != nil {
+			resp.Diagnostics.AddError("Release workflow failed", err.Error())
+			return
+		}
+	}
+	if state.PowerOffDestroy.ValueBool() && workflow != "" && !waitRelease {
+		resp.Diagnostics.AddWarning("Power off skipped",
+			fmt.Sprintf("machine %s was not powered off because its release workflow %s may still be running; set release.wait_for_release_workflow to power it off afterwards.", uuid, workflow))
+		return
+	}
+	if state.PowerOffDestroy.ValueBool() {
+		// The machine is already released, so a failed power off does not fail the destroy.
+		runMachineActions(ctx, r.client, uuid, true, &resp.Diagnostics, "powerOff")
 	}
 }
@@ -0,0 +1,419 @@
+package drpv4
+
+/*
+ * Copyright RackN 2020
+ */
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pborman/uuid"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var (
+	_ resource.Resource                     = (*machineGroupResource)(nil)
+	_ resource.ResourceWithConfigValidators = (*machineGroupResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*machineGroupResource)(nil)
+)
+
+type machineGroupResource struct {
+	client *Config
+}
+
+func NewMachineGroupResource() resource.Resource {
+	return &machineGroupResource{}
+}
+
+func (r *machineGroupResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_machine_group"
+}
+
+func (r *machineGroupResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("machine_count"),
+			path.MatchRoot("max_count"),
+		),
+		resourcevalidator.Conflicting(
+			path.MatchRoot("machine_count"),
+			path.MatchRoot("min_count"),
+		),
+	}
+}
+
+func (r *machineGroupResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Machine group identifier.",
+				MarkdownDescription: "Machine group identifier.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"pool": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("default"),
+				Description:         "Pool to allocate the machines from.",
+				MarkdownDescription: "Pool to allocate the machines from.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"machine_count": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Exact number of machines to allocate.",
+				MarkdownDescription: "Exact number of machines to allocate.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"min_count": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Minimum number of machines to allocate (defaults to max_count).",
+				MarkdownDescription: "Minimum number of machines to allocate (defaults to `max_count`).",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+					int64validator.AtMostSumOf(path.MatchRoot("max_count")),
+				},
+			},
+			"max_count": schema.Int64Attribute{
+				Optional:            true,
+				Description:         "Maximum number of machines to allocate.",
+				MarkdownDescription: "Maximum number of machines to allocate.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"timeout": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("5m"),
+				Description:         "Max time string to wait for pool operations.",
+				MarkdownDescription: "Max time string to wait for pool operations.",
+			},
+			"add_profiles": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Profiles to add to each Machine.Profiles (must already exist).",
+				MarkdownDescription: "Profiles to add to each Machine.Profiles (must already exist).",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"parameters": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Parameters to add to each Machine.Params (values are strings; typed per param definition).",
+				MarkdownDescription: "Parameters to add to each Machine.Params (values are strings; typed per param definition).",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"filters": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Selection filters (Digital Rebar format e.g. FilterVar=value).",
+				MarkdownDescription: "Selection filters (Digital Rebar format e.g. FilterVar=value).",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"machines": schema.ListNestedAttribute{
+				Computed:            true,
+				Description:         "Machines allocated to the group.",
+				MarkdownDescription: "Machines allocated to the group.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id":      schema.StringAttribute{Computed: true, Description: "Machine UUID."},
+						"name":    schema.StringAttribute{Computed: true, Description: "Digital Rebar Machine.Name."},
+						"address": schema.StringAttribute{Computed: true, Description: "Digital Rebar Machine.Address."},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *machineGroupResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type machineGroupResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	Pool         types.String `tfsdk:"pool"`
+	MachineCount types.Int64  `tfsdk:"machine_count"`
+	MinCount     types.Int64  `tfsdk:"min_count"`
+	MaxCount     types.Int64  `tfsdk:"max_count"`
+	Timeout      types.String `tfsdk:"timeout"`
+	AddProfiles  types.List   `tfsdk:"add_profiles"`
+	Parameters   types.Map    `tfsdk:"parameters"`
+	Filters      types.List   `tfsdk:"filters"`
+	Machines     types.List   `tfsdk:"machines"`
+}
+
+type machineGroupMemberModel struct {
+	ID      types.String `tfsdk:"id"`
+	Name    types.String `tfsdk:"name"`
+	Address types.String `tfsdk:"address"`
+}
+
+func machineGroupMemberObjType() types.ObjectType {
+	return types.ObjectType{AttrTypes: map[string]attr.Type{
+		"id":      types.StringType,
+		"name":    types.StringType,
+		"address": types.StringType,
+	}}
+}
+
+// bounds returns the minimum and maximum group size, and false when either is not yet known.
+func (m *machineGroupResourceModel) bounds() (int, int, bool) {
+	if m.MachineCount.IsUnknown() || m.MinCount.IsUnknown() || m.MaxCount.IsUnknown() {
+		return 0, 0, false
+	}
+	if !m.MachineCount.IsNull() {
+		n := int(m.MachineCount.ValueInt64())
+		return n, n, true
+	}
+	maxCount := int(m.MaxCount.ValueInt64())
+	if m.MinCount.IsNull() {
+		return maxCount, maxCount, true
+	}
+	return int(m.MinCount.ValueInt64()), maxCount, true
+}
+
+func (m *machineGroupResourceModel) memberUUIDs(ctx context.Context, diags *diag.Diagnostics) []string {
+	if m.Machines.IsNull() || m.Machines.IsUnknown() {
+		return nil
+	}
+	var members []machineGroupMemberModel
+	diags.Append(m.Machines.ElementsAs(ctx, &members, false)...)
+	out := make([]string, 0, len(members))
+	for _, mm := range members {
+		out = append(out, mm.ID.ValueString())
+	}
+	return out
+}
+
+// ModifyPlan keeps the member list from state unless the group has to grow or shrink
+// to fit the planned bounds, which also makes out-of-band releases show up as a diff.
+func (r *machineGroupResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || req.State.Raw.IsNull() {
+		return
+	}
+	var plan, state machineGroupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	minCount, maxCount, known := plan.bounds()
+	if !known || state.Machines.IsNull() {
+		return
+	}
+	if n := len(state.Machines.Elements()); n < minCount || n > maxCount {
+		return
+	}
+	plan.Machines = state.Machines
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *machineGroupResource) allocateParams(ctx context.Context, m *machineGroupResourceModel, diags *diag.Diagnostics) map[string]interface{} {
+	parms := map[string]interface{}{
+		"pool/wait-timeout": m.Timeout.ValueString(),
+	}
+	if profiles := diagListToStrings(ctx, m.AddProfiles, diags); len(profiles) > 0 {
+		parms["pool/add-profiles"] = profiles
+	}
+	if params := convertParamMap(ctx, r.client, m.Parameters, path.Root("parameters"), diags); len(params) > 0 {
+		parms["pool/add-parameters"] = params
+	}
+	if filters := diagListToStrings(ctx, m.Filters, diags); len(filters) > 0 {
+		parms["pool/filter"] = filters
+	}
+	return parms
+}
+
+// allocate requests between minCount and maxCount machines and returns the UUIDs the
+// group now holds. Machines beyond maxCount are released again immediately.
+func (r *machineGroupResource) allocate(ctx context.Context, m *machineGroupResourceModel, minCount, maxCount int, diags *diag.Diagnostics) []string {
+	pool := m.Pool.ValueString()
+	parms := r.allocateParams(ctx, m, diags)
+	if diags.HasError() {
+		return nil
+	}
+	parms["pool/count"] = maxCount
+	parms["pool/minimum"] = minCount
+	pr, err := allocatePoolMachines(ctx, r.client, pool, parms)
+	if err != nil {
+		diags.AddError("Allocation failed", fmt.Sprintf("Error allocating %d-%d machines from pool %s: %s", minCount, maxCount, pool, err))
+		return nil
+	}
+	uuids := poolResultUUIDs(pr)
+	tflog.Debug(ctx, "allocated machines", map[string]interface{}{"pool": pool, "machines": uuids})
+	if len(uuids) > maxCount {
+		r.release(ctx, m, uuids[maxCount:], diags)
+		uuids = uuids[:maxCount]
+	}
+	if len(uuids) < minCount {
+		diags.AddError("Allocation failed", fmt.Sprintf("Pool %s returned %d machines, need at least %d", pool, len(uuids), minCount))
+		r.release(ctx, m, uuids, diags)
+		return nil
+	}
+	return uuids
+}
+
+func (r *machineGroupResource) release(ctx context.Context, m *machineGroupResourceModel, uuids []string, diags *diag.Diagnostics) {
+	if len(uuids) == 0 {
+		return
+	}
+	pool := m.Pool.ValueString()
+	parms := map[string]interface{}{
+		"pool/wait-timeout": m.Timeout.ValueString(),
+		"pool/machine-list": uuids,
+	}
+	if profiles := diagListToStrings(ctx, m.AddProfiles, diags); len(profiles) > 0 {
+		parms["pool/remove-profiles"] = profiles
+	}
+	if !m.Parameters.IsNull() && !m.Parameters.IsUnknown() && len(m.Parameters.Elements()) > 0 {
+		parms["pool/remove-parameters"] = slices.Sorted(maps.Keys(m.Parameters.Elements()))
+	}
+	pr, err := releasePoolMachines(ctx, r.client, pool, parms)
+	if err != nil {
+		diags.AddError("Release failed", fmt.Sprintf("Error releasing %s from pool %s: %s", strings.Join(uuids, ", "), pool, err))
+		return
+	}
+	for _, p := range pr {
+		if p.Status != "Free" {
+			diags.AddError("Release failed", fmt.Sprintf("Could not release %s from pool %s", p.Uuid, pool))
+		}
+	}
+}
+
+// readMembers refreshes the group members, dropping machines that were deleted or moved
+// out of the pool outside of Terraform.
+func (r *machineGroupResource) readMembers(ctx context.Context, pool string, uuids []string, diags *diag.Diagnostics) types.List {
+	members := make([]machineGroupMemberModel, 0, len(uuids))
+	for _, id := range uuids {
+		mo, err := r.client.getModel(ctx, "machines", id)
+		if err != nil {
+			if isNotFound(err) {
+				tflog.Warn(ctx, "group machine no longer exists", map[string]interface{}{"uuid": id})
+				continue
+			}
+			diags.AddError("Read machine failed", fmt.Sprintf("unable to get machine %s: %s", id, err))
+			continue
+		}
+		machine := mo.(*models.Machine)
+		if machine.Pool != pool || !machine.PoolAllocated {
+			tflog.Warn(ctx, "group machine is no longer allocated in pool", map[string]interface{}{"uuid": id, "pool": machine.Pool})
+			continue
+		}
+		members = append(members, machineGroupMemberModel{
+			ID:      types.StringValue(id),
+			Name:    types.StringValue(machine.Name),
+			Address: types.StringValue(machine.Address.String()),
+		})
+	}
+	lv, d := types.ListValueFrom(ctx, machineGroupMemberObjType(), members)
+	diags.Append(d...)
+	return lv
+}
+
+func (r *machineGroupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan machineGroupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	minCount, maxCount, _ := plan.bounds()
+	uuids := r.allocate(ctx, &plan, minCount, maxCount, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// With min_count = 0 the group may start out empty; it is still saved so later
+	// applies can grow it.
+	plan.ID = types.StringValue(uuid.NewRandom().String())
+	plan.Machines = r.readMembers(ctx, plan.Pool.ValueString(), uuids, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *machineGroupResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state machineGroupResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	uuids := state.memberUUIDs(ctx, &resp.Diagnostics)
+	state.Machines = r.readMembers(ctx, state.Pool.ValueString(), uuids, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *machineGroupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan, state machineGroupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = state.ID
+	pool := plan.Pool.ValueString()
+	uuids := state.memberUUIDs(ctx, &resp.Diagnostics)
+	minCount, maxCount, _ := plan.bounds()
+
+	switch n := len(uuids); {
+	case n < minCount:
+		uuids = append(uuids, r.allocate(ctx, &plan, minCount-n, maxCount-n, &resp.Diagnostics)...)
+	case n > maxCount:
+		r.release(ctx, &state, uuids[maxCount:], &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		uuids = uuids[:maxCount]
+	}
+	plan.Machines = r.readMembers(ctx, pool, uuids, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *machineGroupResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state machineGroupResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.release(ctx, &state, state.memberUUIDs(ctx, &resp.Diagnostics), &resp.Diagnostics)
+}
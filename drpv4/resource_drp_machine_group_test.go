@@ -0,0 +1,170 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+func TestAccMachineGroupResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_machine_group" "test" {
+						machine_count = 2
+						max_count     = 3
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: `
+					resource "drp_machine_group" "test" {
+						min_count = 4
+						max_count = 2
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Value"),
+			},
+		},
+	})
+}
+
+func TestAccMachineGroupResourcePool(t *testing.T) {
+	name := fmt.Sprintf("tf-group-%s", accRandomSuffix(10))
+	var c *Config
+	config := func(counts string) string {
+		return fmt.Sprintf(`
+			resource "drp_machine_group" "test" {
+				filters = ["Name=Re(^%s-)"]
+				timeout = "1m"
+				%s
+			}
+		`, name, counts)
+	}
+	// allocated returns the test machines the default pool holds as allocated.
+	allocated := func() ([]*models.Machine, error) {
+		mo, err := c.listModel(context.Background(), "machines", "Name", fmt.Sprintf("Re(^%s-)", name))
+		if err != nil {
+			return nil, err
+		}
+		var out []*models.Machine
+		for _, o := range mo {
+			if m := o.(*models.Machine); m.Pool == "default" && m.PoolAllocated {
+				out = append(out, m)
+			}
+		}
+		return out, nil
+	}
+	// checkAllocated checks that exactly the group members are allocated in the pool.
+	checkAllocated := func(s *terraform.State) error {
+		rs := s.RootModule().Resources["drp_machine_group.test"]
+		if rs == nil {
+			return fmt.Errorf("drp_machine_group.test not in state")
+		}
+		ms, err := allocated()
+		if err != nil {
+			return err
+		}
+		if want := rs.Primary.Attributes["machines.#"]; fmt.Sprint(len(ms)) != want {
+			return fmt.Errorf("%d machines allocated in pool default, want %s", len(ms), want)
+		}
+		members := map[string]bool{}
+		for k, v := range rs.Primary.Attributes {
+			if strings.HasPrefix(k, "machines.") && strings.HasSuffix(k, ".id") {
+				members[v] = true
+			}
+		}
+		for _, m := range ms {
+			if !members[m.UUID()] {
+				return fmt.Errorf("machine %s is allocated but not a group member", m.Name)
+			}
+		}
+		return nil
+	}
+	// releaseAll releases the group members outside of Terraform.
+	releaseAll := func() {
+		ms, err := allocated()
+		if err != nil {
+			t.Fatal(err)
+		}
+		uuids := make([]string, 0, len(ms))
+		for _, m := range ms {
+			uuids = append(uuids, m.UUID())
+		}
+		if _, err := releasePoolMachines(context.Background(), c, "default", map[string]interface{}{"pool/machine-list": uuids}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck: func() {
+			testAccPreCheck(t)
+			c = testAccClient(t)
+			testAccMachines(t, c, name, 3)
+		},
+		CheckDestroy: func(*terraform.State) error {
+			ms, err := allocated()
+			if err != nil {
+				return err
+			}
+			if len(ms) != 0 {
+				return fmt.Errorf("%d machines still allocated after destroy", len(ms))
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config("machine_count = 2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("drp_machine_group.test", "id"),
+					resource.TestCheckResourceAttr("drp_machine_group.test", "pool", "default"),
+					resource.TestCheckResourceAttr("drp_machine_group.test", "machines.#", "2"),
+					resource.TestMatchResourceAttr("drp_machine_group.test", "machines.0.name", regexp.MustCompile("^"+name+"-")),
+					checkAllocated,
+				),
+			},
+			{
+				Config: config("machine_count = 3"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_machine_group.test", "machines.#", "3"),
+					checkAllocated,
+				),
+			},
+			{
+				Config: config("machine_count = 1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_machine_group.test", "machines.#", "1"),
+					checkAllocated,
+				),
+			},
+			{
+				// A member released outside of Terraform drops out on refresh and is replaced.
+				PreConfig: releaseAll,
+				Config:    config("machine_count = 1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_machine_group.test", "machines.#", "1"),
+					checkAllocated,
+				),
+			},
+			{
+				// With min_count = 0 an emptied group stays empty.
+				PreConfig: releaseAll,
+				Config:    config("min_count = 0\nmax_count = 2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_machine_group.test", "machines.#", "0"),
+					checkAllocated,
+				),
+			},
+		},
+	})
+}
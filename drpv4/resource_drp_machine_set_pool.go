@@ -17,10 +17,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"gitlab.com/rackn/jp"
+	"gitlab.com/rackn/provision/v4/api"
 	"gitlab.com/rackn/provision/v4/models"
 )
 
 var _ resource.Resource = (*machineSetPoolResource)(nil)
+var _ resource.ResourceWithImportState = (*machineSetPoolResource)(nil)
 
 type machineSetPoolResource struct {
 	client *Config
@@ -80,6 +82,26 @@ type machineSetPoolResourceModel struct {
 	Address types.String `tfsdk:"address"`
 }
 
+// ImportState adopts a machine by UUID or name:<machine-name> in whatever pool it is in.
+func (r *machineSetPoolResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Import machine failed", "provider is not configured")
+		return
+	}
+	m, err := importMachine(ctx, r.client, req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Import machine failed", err.Error())
+		return
+	}
+	state := machineSetPoolResourceModel{
+		ID:      types.StringValue(m.Uuid.String()),
+		Pool:    types.StringValue(m.Pool),
+		Name:    types.StringValue(m.Name),
+		Address: types.StringValue(m.Address.String()),
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
 func (r *machineSetPoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	if r.client == nil {
 		return
@@ -96,7 +118,7 @@ func (r *machineSetPoolResource) Create(ctx context.Context, req resource.Create
 	}
 	name := plan.Name.ValueString()
 
-	mo, err := r.client.session.ListModel("machines", "Name", name)
+	mo, err := r.client.listModel(ctx, "machines", "Name", name)
 	if err != nil || len(mo) != 1 {
 		resp.Diagnostics.AddError("Machine lookup failed", fmt.Sprintf("unable to get machine %s", name))
 		return
@@ -115,9 +137,10 @@ func (r *machineSetPoolResource) Create(ctx context.Context, req resource.Create
 			resp.Diagnostics.AddError("Set pool failed", fmt.Sprintf("build patch: %s", err))
 			return
 		}
-		reqm := r.client.session.Req().Patch(patch).UrlFor("machines", machineObject.Uuid.String())
 		mr := models.Machine{}
-		if err := reqm.Do(&mr); err != nil {
+		if err := r.client.do(ctx, func() *api.R {
+			return r.client.session.Req().Patch(patch).UrlFor("machines", machineObject.Uuid.String())
+		}, &mr); err != nil {
 			resp.Diagnostics.AddError("Set pool failed", fmt.Sprintf("error setting pool %s: %s", pool, err))
 			return
 		}
@@ -140,11 +163,11 @@ func (r *machineSetPoolResource) Read(ctx context.Context, req resource.ReadRequ
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
-func (r *machineSetPoolResource) readMachineSetPool(_ context.Context, uuid string, m *machineSetPoolResourceModel, diags *diag.Diagnostics) {
+func (r *machineSetPoolResource) readMachineSetPool(ctx context.Context, uuid string, m *machineSetPoolResourceModel, diags *diag.Diagnostics) {
 	if uuid == "" {
 		return
 	}
-	mo, err := r.client.session.GetModel("machines", uuid)
+	mo, err := r.client.getModel(ctx, "machines", uuid)
 	if err != nil {
 		if isNotFound(err) {
 			m.ID = types.StringNull()
@@ -191,9 +214,10 @@ func (r *machineSetPoolResource) Delete(ctx context.Context, req resource.Delete
 		resp.Diagnostics.AddError("Delete failed", fmt.Sprintf("build patch: %s", err))
 		return
 	}
-	reqm := r.client.session.Req().Patch(patch).UrlFor("machines", uuid)
 	mr := models.Machine{}
-	if err := reqm.Do(&mr); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Patch(patch).UrlFor("machines", uuid)
+	}, &mr); err != nil {
 		resp.Diagnostics.AddError("Delete failed", fmt.Sprintf("error setting pool default: %s", err))
 		return
 	}
@@ -0,0 +1,28 @@
+package drpv4
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccMachineSetPoolResourceImport(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_machine_set_pool" "test" {
+						name = "tf-missing-machine"
+					}
+				`,
+				ResourceName:  "drp_machine_set_pool.test",
+				ImportState:   true,
+				ImportStateId: "name:tf-missing-machine",
+				ExpectError:   regexp.MustCompile("found 0 machines named tf-missing-machine"),
+			},
+		},
+	})
+}
@@ -0,0 +1,136 @@
+package drpv4
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/pborman/uuid"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+func TestAccMachineResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_machine" "test" {}
+				`,
+				ResourceName:  "drp_machine.test",
+				ImportState:   true,
+				ImportStateId: "name:tf-missing-machine",
+				ExpectError:   regexp.MustCompile("found 0 machines named tf-missing-machine"),
+			},
+			{
+				Config: `
+					resource "drp_machine" "test" {
+						release = {
+							wait_for_release_workflow = true
+						}
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid Attribute Combination"),
+			},
+			{
+				Config: `
+					resource "drp_machine" "test" {
+						wait_for_capacity = true
+						capacity_timeout  = "soon"
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid capacity_timeout"),
+			},
+		},
+	})
+}
+
+func TestMachineDeleteForceRelease(t *testing.T) {
+	ctx := context.Background()
+	defer func(d time.Duration) { machinePollInterval = d }(machinePollInterval)
+	machinePollInterval = time.Millisecond
+
+	const id = "3dc7b5d4-98a4-4c4d-9a31-3a4b8e0c6f11"
+	forced, release := uuid.NewRandom(), uuid.NewRandom()
+	var mu sync.Mutex
+	var jobPatches []string
+	released, pollsAfterRelease := false, 0
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v3/jobs/"+forced.String():
+			b, _ := io.ReadAll(r.Body)
+			jobPatches = append(jobPatches, string(b))
+			json.NewEncoder(w).Encode(&models.Job{Uuid: forced, State: "failed"})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/pools/p1/releaseMachines":
+			released = true
+			json.NewEncoder(w).Encode([]*models.PoolResult{{Uuid: id, Status: models.PS_IN_USE}})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/machines/"+id:
+			m := testMachine("deploy", "running", forced, false)
+			m.PoolStatus = models.PS_IN_USE
+			switch {
+			case len(jobPatches) > 0 && (!released || pollsAfterRelease < 2):
+				// The forced job stays current, and failed, until the release workflow starts.
+				m.JobState = "failed"
+			case released:
+				m.Workflow, m.JobState, m.CurrentJob, m.WorkflowComplete = "release", "finished", release, true
+			}
+			if released {
+				pollsAfterRelease++
+			}
+			json.NewEncoder(w).Encode(m)
+		default:
+			writeTestError(w, http.StatusNotFound)
+		}
+	})
+
+	r := &machineResource{client: c}
+	var sresp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &sresp)
+	s := sresp.Schema
+	st := tfsdk.State{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+	set := func(p path.Path, v interface{}) {
+		if d := st.SetAttribute(ctx, p, v); d.HasError() {
+			t.Fatal(d)
+		}
+	}
+	set(path.Root("id"), id)
+	set(path.Root("pool"), "p1")
+	set(path.Root("timeout"), "1m")
+	set(path.Root("wait_timeout"), "1m")
+	releaseObj := types.ObjectValueMust(machineReleaseAttrTypes(), map[string]attr.Value{
+		"workflow":                  types.StringValue("release"),
+		"wait_for_release_workflow": types.BoolValue(true),
+		"force":                     types.BoolValue(true),
+		"keep_on_destroy":           types.BoolValue(false),
+	})
+	set(path.Root("release"), releaseObj)
+
+	var resp fwresource.DeleteResponse
+	r.Delete(ctx, fwresource.DeleteRequest{State: st}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Delete() diagnostics = %v", resp.Diagnostics)
+	}
+	if len(jobPatches) != 1 || !strings.Contains(jobPatches[0], `"failed"`) {
+		t.Errorf("job patches = %v, want the running job marked failed", jobPatches)
+	}
+	if pollsAfterRelease < 3 {
+		t.Errorf("polled %d times after release, want the wait to continue past the forced job", pollsAfterRelease)
+	}
+}
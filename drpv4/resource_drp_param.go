@@ -142,11 +142,13 @@ func (r *paramResource) Create(ctx context.Context, req resource.CreateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(param); err != nil {
+	err := r.client.createModel(ctx, param)
+	r.client.params.invalidate(param.Name)
+	if err != nil {
 		resp.Diagnostics.AddError("Create param failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("params", plan.Name.ValueString())
+	got, err := r.client.getModel(ctx, "params", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read param after create failed", err.Error())
 		return
@@ -164,7 +166,7 @@ func (r *paramResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	po, err := r.client.session.GetModel("params", state.Name.ValueString())
+	po, err := r.client.getModel(ctx, "params", state.Name.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -173,6 +175,7 @@ func (r *paramResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		resp.Diagnostics.AddError("Read param failed", err.Error())
 		return
 	}
+	warnIfContentOwned(po, &resp.Diagnostics)
 	r.flattenParam(ctx, po.(*models.Param), &state, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -190,11 +193,13 @@ func (r *paramResource) Update(ctx context.Context, req resource.UpdateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(param); err != nil {
+	err := r.client.putModel(ctx, param)
+	r.client.params.invalidate(param.Name)
+	if err != nil {
 		resp.Diagnostics.AddError("Update param failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("params", plan.Name.ValueString())
+	got, err := r.client.getModel(ctx, "params", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read param after update failed", err.Error())
 		return
@@ -212,7 +217,9 @@ func (r *paramResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("params", state.Name.ValueString()); err != nil {
+	_, err := r.client.deleteModel(ctx, "params", state.Name.ValueString())
+	r.client.params.invalidate(state.Name.ValueString())
+	if err != nil {
 		resp.Diagnostics.AddError("Delete param failed", err.Error())
 	}
 }
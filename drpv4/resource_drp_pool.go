@@ -17,6 +17,7 @@ import (
 
 var _ resource.Resource = (*poolResource)(nil)
 var _ resource.ResourceWithImportState = (*poolResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*poolResource)(nil)
 
 type poolResource struct {
 	client *Config
@@ -120,6 +121,37 @@ type poolResourceModel struct {
 	Autofill        types.List   `tfsdk:"autofill"`
 }
 
+// ModifyPlan validates add_parameters of every action block against the schemas of their
+// param definitions and checks the referenced parent pool and action workflows.
+func (r *poolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var parent types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("parent_pool"), &parent)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateReference(ctx, r.client, "pools", parent, path.Root("parent_pool"), &resp.Diagnostics)
+	for _, name := range []string{"allocate_actions", "release_actions", "enter_actions", "exit_actions"} {
+		var l types.List
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(name), &l)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		for i, ov := range l.Elements() {
+			o, ok := ov.(types.Object)
+			if !ok || o.IsNull() || o.IsUnknown() {
+				continue
+			}
+			if m, ok := o.Attributes()["add_parameters"].(types.Map); ok {
+				validateParamMap(ctx, r.client, m, path.Root(name).AtListIndex(i).AtName("add_parameters"), &resp.Diagnostics)
+			}
+			validateReference(ctx, r.client, "workflows", priorObjString(o, "workflow"), path.Root(name).AtListIndex(i).AtName("workflow"), &resp.Diagnostics)
+		}
+	}
+}
+
 func poolActionObjType() types.ObjectType {
 	return types.ObjectType{AttrTypes: map[string]attr.Type{
 		"add_parameters":    types.MapType{ElemType: types.StringType},
@@ -178,7 +210,7 @@ func (r *poolResource) expandPoolActions(ctx context.Context, l types.List, diag
 			var sm map[string]string
 			diags.Append(mmap.ElementsAs(ctx, &sm, false)...)
 			for k, v := range sm {
-				val, err := convertParamToType(r.client, k, v)
+				val, err := convertParamToType(ctx, r.client, k, v)
 				if err != nil {
 					diags.AddError("Invalid add_parameters value", err.Error())
 					return nil
@@ -357,11 +389,11 @@ func (r *poolResource) Create(ctx context.Context, req resource.CreateRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(pool); err != nil {
+	if err := r.client.createModel(ctx, pool); err != nil {
 		resp.Diagnostics.AddError("Create pool failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("pools", plan.PoolID.ValueString())
+	got, err := r.client.getModel(ctx, "pools", plan.PoolID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read pool after create failed", err.Error())
 		return
@@ -379,7 +411,7 @@ func (r *poolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	pool, err := r.client.session.GetModel("pools", state.PoolID.ValueString())
+	pool, err := r.client.getModel(ctx, "pools", state.PoolID.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -405,11 +437,11 @@ func (r *poolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(pool); err != nil {
+	if err := r.client.putModel(ctx, pool); err != nil {
 		resp.Diagnostics.AddError("Update pool failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("pools", plan.PoolID.ValueString())
+	got, err := r.client.getModel(ctx, "pools", plan.PoolID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read pool after update failed", err.Error())
 		return
@@ -427,7 +459,7 @@ func (r *poolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("pools", state.PoolID.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "pools", state.PoolID.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete pool failed", err.Error())
 	}
 }
@@ -109,11 +109,11 @@ func (r *profileResource) Create(ctx context.Context, req resource.CreateRequest
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(profile); err != nil {
+	if err := r.client.createModel(ctx, profile); err != nil {
 		resp.Diagnostics.AddError("Create profile failed", err.Error())
 		return
 	}
-	res, err := r.client.session.GetModel("profiles", profile.Name)
+	res, err := r.client.getModel(ctx, "profiles", profile.Name)
 	if err != nil {
 		resp.Diagnostics.AddError("Read profile after create failed", err.Error())
 		return
@@ -131,7 +131,7 @@ func (r *profileResource) Read(ctx context.Context, req resource.ReadRequest, re
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	pr, err := r.client.session.GetModel("profiles", state.Name.ValueString())
+	pr, err := r.client.getModel(ctx, "profiles", state.Name.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -141,6 +141,7 @@ func (r *profileResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 	p := pr.(*models.Profile)
+	warnIfContentOwned(p, &resp.Diagnostics)
 	r.flattenProfile(ctx, p, &state, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -158,11 +159,11 @@ func (r *profileResource) Update(ctx context.Context, req resource.UpdateRequest
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(profile); err != nil {
+	if err := r.client.putModel(ctx, profile); err != nil {
 		resp.Diagnostics.AddError("Update profile failed", err.Error())
 		return
 	}
-	res, err := r.client.session.GetModel("profiles", plan.Name.ValueString())
+	res, err := r.client.getModel(ctx, "profiles", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read profile after update failed", err.Error())
 		return
@@ -180,7 +181,7 @@ func (r *profileResource) Delete(ctx context.Context, req resource.DeleteRequest
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("profiles", state.Name.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "profiles", state.Name.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete profile failed", err.Error())
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/api"
 	"gitlab.com/rackn/provision/v4/models"
 )
 
@@ -20,6 +21,7 @@ var (
 	_ resource.Resource                     = (*profileParamResource)(nil)
 	_ resource.ResourceWithImportState      = (*profileParamResource)(nil)
 	_ resource.ResourceWithConfigValidators = (*profileParamResource)(nil)
+	_ resource.ResourceWithModifyPlan       = (*profileParamResource)(nil)
 )
 
 type profileParamResource struct {
@@ -98,6 +100,22 @@ func (r *profileParamResource) ImportState(ctx context.Context, req resource.Imp
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), types.StringValue(parts[1]))...)
 }
 
+// ModifyPlan validates value against the schema of the param definition.
+func (r *profileParamResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan profileParamResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.Name.IsUnknown() || plan.Value.IsNull() || plan.Value.IsUnknown() {
+		return
+	}
+	validateParamValue(ctx, r.client, plan.Name.ValueString(), plan.Value.ValueString(), path.Root("value"), &resp.Diagnostics)
+}
+
 func (r *profileParamResource) upsert(ctx context.Context, m *profileParamResourceModel, diags *diag.Diagnostics) {
 	profile := m.Profile.ValueString()
 	name := m.Name.ValueString()
@@ -112,7 +130,7 @@ func (r *profileParamResource) upsert(ctx context.Context, m *profileParamResour
 		return
 	}
 
-	if value != "" && isParamSecure(r.client, name) {
+	if value != "" && isParamSecure(ctx, r.client, name) {
 		diags.AddError(
 			"Invalid profile param",
 			fmt.Sprintf("Param %s is secure; use secure_value instead.", name),
@@ -120,11 +138,9 @@ func (r *profileParamResource) upsert(ctx context.Context, m *profileParamResour
 		return
 	}
 
-	req := r.client.session.Req().UrlFor("profiles", profile, "params", name)
-
 	if secureValue != "" {
 		sv := &models.SecureData{}
-		pubkey, err := getPublicKey(r.client, profile)
+		pubkey, err := getPublicKey(ctx, r.client, profile)
 		if err != nil {
 			diags.AddError("Read profile pubkey failed", err.Error())
 			return
@@ -137,19 +153,23 @@ func (r *profileParamResource) upsert(ctx context.Context, m *profileParamResour
 			diags.AddError("Validate secure value failed", err.Error())
 			return
 		}
-		if err := req.Post(sv).Do(nil); err != nil {
+		if err := r.client.do(ctx, func() *api.R {
+			return r.client.session.Req().Post(sv).UrlFor("profiles", profile, "params", name)
+		}, nil); err != nil {
 			diags.AddError("Set secure profile param failed", err.Error())
 			return
 		}
 		return
 	}
 
-	convertedValue, err := convertParamToType(r.client, name, value)
+	convertedValue, err := convertParamToType(ctx, r.client, name, value)
 	if err != nil {
 		diags.AddError("Convert param value failed", err.Error())
 		return
 	}
-	if err := req.Post(convertedValue).Do(nil); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Post(convertedValue).UrlFor("profiles", profile, "params", name)
+	}, nil); err != nil {
 		diags.AddError("Set profile param failed", err.Error())
 	}
 }
@@ -160,7 +180,9 @@ func (r *profileParamResource) readIntoModel(ctx context.Context, m *profilePara
 	secureValue := m.SecureValue.ValueString()
 
 	var p interface{}
-	if err := r.client.session.Req().UrlFor("profiles", profile, "params", name).Do(&p); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().UrlFor("profiles", profile, "params", name)
+	}, &p); err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			m.Profile = types.StringNull()
 			m.Name = types.StringNull()
@@ -186,7 +208,9 @@ func (r *profileParamResource) readIntoModel(ctx context.Context, m *profilePara
 
 	m.Value = types.StringNull()
 	var securedValue string
-	if err := r.client.session.Req().UrlFor("profiles", profile, "params", name).Params("decode", "true").Do(&securedValue); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().UrlFor("profiles", profile, "params", name).Params("decode", "true")
+	}, &securedValue); err != nil {
 		diags.AddError("Read decoded secure param failed", err.Error())
 		return
 	}
@@ -253,7 +277,9 @@ func (r *profileParamResource) Delete(ctx context.Context, req resource.DeleteRe
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.Req().Del().UrlFor("profiles", state.Profile.ValueString(), "params", state.Name.ValueString()).Do(nil); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Del().UrlFor("profiles", state.Profile.ValueString(), "params", state.Name.ValueString())
+	}, nil); err != nil {
 		resp.Diagnostics.AddError("Delete profile param failed", err.Error())
 	}
 }
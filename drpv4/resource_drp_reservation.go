@@ -180,11 +180,11 @@ func (r *reservationResource) Create(ctx context.Context, req resource.CreateReq
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(res); err != nil {
+	if err := r.client.createModel(ctx, res); err != nil {
 		resp.Diagnostics.AddError("Create reservation failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("reservations", plan.Address.ValueString())
+	got, err := r.client.getModel(ctx, "reservations", plan.Address.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read reservation after create failed", err.Error())
 		return
@@ -202,7 +202,7 @@ func (r *reservationResource) Read(ctx context.Context, req resource.ReadRequest
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	res, err := r.client.session.GetModel("reservations", state.Address.ValueString())
+	res, err := r.client.getModel(ctx, "reservations", state.Address.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -228,11 +228,11 @@ func (r *reservationResource) Update(ctx context.Context, req resource.UpdateReq
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(res); err != nil {
+	if err := r.client.putModel(ctx, res); err != nil {
 		resp.Diagnostics.AddError("Update reservation failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("reservations", plan.Address.ValueString())
+	got, err := r.client.getModel(ctx, "reservations", plan.Address.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read reservation after update failed", err.Error())
 		return
@@ -250,7 +250,7 @@ func (r *reservationResource) Delete(ctx context.Context, req resource.DeleteReq
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("reservations", state.Address.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "reservations", state.Address.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete reservation failed", err.Error())
 	}
 }
@@ -0,0 +1,183 @@
+package drpv4
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*roleResource)(nil)
+var _ resource.ResourceWithImportState = (*roleResource)(nil)
+
+type roleResource struct {
+	client *Config
+}
+
+func NewRoleResource() resource.Resource {
+	return &roleResource{}
+}
+
+func (r *roleResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_role"
+}
+
+func (r *roleResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "Role name.",
+				MarkdownDescription: "Role name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"description": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Role description.",
+				MarkdownDescription: "Role description.",
+			},
+			"documentation": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Role documentation.",
+				MarkdownDescription: "Role documentation.",
+			},
+			"claims": schema.ListNestedAttribute{
+				Optional:     true,
+				NestedObject: schema.NestedAttributeObject{Attributes: taskClaimNestedAttributes()},
+				Description:  "Claims granted by the role.",
+			},
+		},
+	}
+}
+
+func (r *roleResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type roleResourceModel struct {
+	Name          types.String `tfsdk:"name"`
+	Description   types.String `tfsdk:"description"`
+	Documentation types.String `tfsdk:"documentation"`
+	Claims        types.List   `tfsdk:"claims"`
+}
+
+func (r *roleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
+func (r *roleResource) expandRole(ctx context.Context, m *roleResourceModel, diags *diag.Diagnostics) *models.Role {
+	claims := expandClaims(ctx, m.Claims, diags)
+	if diags.HasError() {
+		return nil
+	}
+	return &models.Role{
+		Name:    m.Name.ValueString(),
+		DocData: newDocData(m.Description.ValueString(), m.Documentation.ValueString()),
+		Claims:  claims,
+	}
+}
+
+func (r *roleResource) flattenRole(ctx context.Context, role *models.Role, m *roleResourceModel, diags *diag.Diagnostics) {
+	m.Name = types.StringValue(role.Name)
+	m.Description = mergeOptString(m.Description, role.Description)
+	m.Documentation = mergeOptString(m.Documentation, role.Documentation)
+	m.Claims = flattenTaskClaimsMerged(ctx, m.Claims, role.Claims, diags)
+}
+
+func (r *roleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan roleResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	role := r.expandRole(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.createModel(ctx, role); err != nil {
+		resp.Diagnostics.AddError("Create role failed", err.Error())
+		return
+	}
+	got, err := r.client.getModel(ctx, "roles", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read role after create failed", err.Error())
+		return
+	}
+	r.flattenRole(ctx, got.(*models.Role), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *roleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state roleResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	res, err := r.client.getModel(ctx, "roles", state.Name.ValueString())
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "Not Found") {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read role failed", err.Error())
+		return
+	}
+	warnIfContentOwned(res, &resp.Diagnostics)
+	r.flattenRole(ctx, res.(*models.Role), &state, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *roleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan roleResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	role := r.expandRole(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.putModel(ctx, role); err != nil {
+		resp.Diagnostics.AddError("Update role failed", err.Error())
+		return
+	}
+	got, err := r.client.getModel(ctx, "roles", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read role after update failed", err.Error())
+		return
+	}
+	r.flattenRole(ctx, got.(*models.Role), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *roleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state roleResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.deleteModel(ctx, "roles", state.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddError("Delete role failed", err.Error())
+	}
+}
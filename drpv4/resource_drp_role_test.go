@@ -0,0 +1,55 @@
+package drpv4
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccRoleResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_role" "test" {
+						name = "test-role"
+						description = "test"
+						claims = [{
+							scope = "machines"
+							action = "get"
+							specific = "*"
+						}]
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_role.test", "name", "test-role"),
+					resource.TestCheckResourceAttr("drp_role.test", "claims.#", "1"),
+					resource.TestCheckResourceAttr("drp_role.test", "claims.0.scope", "machines"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_role" "test" {
+						name = "test-role"
+						description = "test"
+						claims = [{
+							scope = "machines"
+							action = "get"
+							specific = "*"
+						}, {
+							scope = "profiles"
+							action = "list"
+							specific = "*"
+						}]
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_role.test", "claims.#", "2"),
+					resource.TestCheckResourceAttr("drp_role.test", "claims.1.action", "list"),
+				),
+			},
+		},
+	})
+}
@@ -17,6 +17,7 @@ import (
 
 var _ resource.Resource = (*stageResource)(nil)
 var _ resource.ResourceWithImportState = (*stageResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*stageResource)(nil)
 
 type stageResource struct {
 	client *Config
@@ -31,7 +32,7 @@ func (r *stageResource) Metadata(_ context.Context, _ resource.MetadataRequest,
 }
 
 func stageTemplateNestedAttributes() map[string]schema.Attribute {
-	return map[string]schema.Attribute{
+	return templateSourceNestedAttributes(map[string]schema.Attribute{
 		"name":        schema.StringAttribute{Required: true, Description: "Template name."},
 		"contents":    schema.StringAttribute{Optional: true, Description: "Template content."},
 		"path":        schema.StringAttribute{Optional: true, Description: "Template path."},
@@ -42,7 +43,7 @@ func stageTemplateNestedAttributes() map[string]schema.Attribute {
 			Optional:    true,
 			Description: "Template meta.",
 		},
-	}
+	})
 }
 
 func (r *stageResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
@@ -123,6 +124,24 @@ func (r *stageResource) ImportState(ctx context.Context, req resource.ImportStat
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
 }
 
+// ModifyPlan plans template hashes, validates params against the schemas of their param
+// definitions and checks referenced tasks, profiles and bootenv.
+func (r *stageResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanTemplateHashes(ctx, req, resp, path.Root("template"))
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan stageResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateParamMap(ctx, r.client, plan.Params, path.Root("params"), &resp.Diagnostics)
+	validateReferenceList(ctx, r.client, "tasks", plan.Tasks, path.Root("tasks"), &resp.Diagnostics)
+	validateReferenceList(ctx, r.client, "profiles", plan.Profiles, path.Root("profiles"), &resp.Diagnostics)
+	validateReference(ctx, r.client, "bootenvs", plan.BootEnv, path.Root("bootenv"), &resp.Diagnostics)
+}
+
 func stageTemplateObjType() types.ObjectType {
 	return types.ObjectType{AttrTypes: map[string]attr.Type{
 		"name":        types.StringType,
@@ -131,6 +150,9 @@ func stageTemplateObjType() types.ObjectType {
 		"template_id": types.StringType,
 		"link":        types.StringType,
 		"meta":        types.MapType{ElemType: types.StringType},
+
+		"source_file":     types.StringType,
+		"contents_sha256": types.StringType,
 	}}
 }
 
@@ -140,7 +162,7 @@ func (r *stageResource) expandStageTemplates(ctx context.Context, l types.List,
 	}
 	els := l.Elements()
 	out := make([]models.TemplateInfo, 0, len(els))
-	for _, el := range els {
+	for i, el := range els {
 		o, ok := el.(types.Object)
 		if !ok {
 			diags.AddError("Invalid template element", "expected object")
@@ -155,7 +177,7 @@ func (r *stageResource) expandStageTemplates(ctx context.Context, l types.List,
 		}
 		out = append(out, models.TemplateInfo{
 			Name:     objectAttrString(o, "name"),
-			Contents: objectAttrString(o, "contents"),
+			Contents: templateBody(priorObjString(o, "contents"), priorObjString(o, "source_file"), path.Root("template").AtListIndex(i), diags),
 			Path:     objectAttrString(o, "path"),
 			ID:       objectAttrString(o, "template_id"),
 			Link:     objectAttrString(o, "link"),
@@ -230,11 +252,14 @@ func (r *stageResource) flattenStageTemplatesMerged(ctx context.Context, prior t
 		metaVal := mergeOptStringMap(ctx, priorObjMap(pObj, "meta"), meta, diags)
 		attrs := map[string]attr.Value{
 			"name":        types.StringValue(ti.Name),
-			"contents":    mergeOptString(priorObjString(pObj, "contents"), ti.Contents),
+			"contents":    flattenTemplateContents(priorObjString(pObj, "contents"), priorObjString(pObj, "source_file"), ti.Contents),
 			"path":        mergeOptString(priorObjString(pObj, "path"), ti.Path),
 			"template_id": mergeOptString(priorObjString(pObj, "template_id"), ti.ID),
 			"link":        mergeOptString(priorObjString(pObj, "link"), ti.Link),
 			"meta":        metaVal,
+
+			"source_file":     priorObjString(pObj, "source_file"),
+			"contents_sha256": contentsSHA256(ti.Contents),
 		}
 		obj, d := types.ObjectValue(stageTemplateObjType().AttrTypes, attrs)
 		diags.Append(d...)
@@ -286,11 +311,11 @@ func (r *stageResource) Create(ctx context.Context, req resource.CreateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(stage); err != nil {
+	if err := r.client.createModel(ctx, stage); err != nil {
 		resp.Diagnostics.AddError("Create stage failed", err.Error())
 		return
 	}
-	res, err := r.client.session.GetModel("stages", stage.Name)
+	res, err := r.client.getModel(ctx, "stages", stage.Name)
 	if err != nil {
 		resp.Diagnostics.AddError("Read stage after create failed", err.Error())
 		return
@@ -308,7 +333,7 @@ func (r *stageResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	res, err := r.client.session.GetModel("stages", state.Name.ValueString())
+	res, err := r.client.getModel(ctx, "stages", state.Name.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -317,6 +342,7 @@ func (r *stageResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		resp.Diagnostics.AddError("Read stage failed", err.Error())
 		return
 	}
+	warnIfContentOwned(res, &resp.Diagnostics)
 	r.flattenStage(ctx, res.(*models.Stage), &state, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -334,11 +360,11 @@ func (r *stageResource) Update(ctx context.Context, req resource.UpdateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(stage); err != nil {
+	if err := r.client.putModel(ctx, stage); err != nil {
 		resp.Diagnostics.AddError("Update stage failed", err.Error())
 		return
 	}
-	res, err := r.client.session.GetModel("stages", plan.Name.ValueString())
+	res, err := r.client.getModel(ctx, "stages", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read stage after update failed", err.Error())
 		return
@@ -356,7 +382,7 @@ func (r *stageResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("stages", state.Name.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "stages", state.Name.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete stage failed", err.Error())
 	}
 }
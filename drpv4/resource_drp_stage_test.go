@@ -96,6 +96,23 @@ func TestAccStageResource(t *testing.T) {
 					resource.TestCheckResourceAttr("drp_stage.test", "optional_params.#", "0"),
 				),
 			},
+			{
+				// Param values are sent as the configured strings and must come back as written.
+				Config: `
+					resource "drp_stage" "test" {
+						name = "test"
+						params = {
+							test            = "test"
+							"tf-acc-obj"    = "{ \"a\": 1 }"
+							"tf-acc-number" = "1.0"
+						}
+					}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_stage.test", "params.%", "3"),
+					resource.TestCheckResourceAttr("drp_stage.test", "params.tf-acc-obj", "{ \"a\": 1 }"),
+					resource.TestCheckResourceAttr("drp_stage.test", "params.tf-acc-number", "1.0"),
+				),
+			},
 			{
 				Config: `
 					resource "drp_stage" "test" {
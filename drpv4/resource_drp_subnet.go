@@ -245,11 +245,11 @@ func (r *subnetResource) Create(ctx context.Context, req resource.CreateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(sub); err != nil {
+	if err := r.client.createModel(ctx, sub); err != nil {
 		resp.Diagnostics.AddError("Create subnet failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("subnets", plan.Name.ValueString())
+	got, err := r.client.getModel(ctx, "subnets", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read subnet after create failed", err.Error())
 		return
@@ -267,7 +267,7 @@ func (r *subnetResource) Read(ctx context.Context, req resource.ReadRequest, res
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	res, err := r.client.session.GetModel("subnets", state.Name.ValueString())
+	res, err := r.client.getModel(ctx, "subnets", state.Name.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -293,11 +293,11 @@ func (r *subnetResource) Update(ctx context.Context, req resource.UpdateRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(sub); err != nil {
+	if err := r.client.putModel(ctx, sub); err != nil {
 		resp.Diagnostics.AddError("Update subnet failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("subnets", plan.Name.ValueString())
+	got, err := r.client.getModel(ctx, "subnets", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read subnet after update failed", err.Error())
 		return
@@ -315,7 +315,7 @@ func (r *subnetResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("subnets", state.Name.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "subnets", state.Name.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete subnet failed", err.Error())
 	}
 }
@@ -17,6 +17,7 @@ import (
 
 var _ resource.Resource = (*taskResource)(nil)
 var _ resource.ResourceWithImportState = (*taskResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*taskResource)(nil)
 
 type taskResource struct {
 	client *Config
@@ -31,7 +32,7 @@ func (r *taskResource) Metadata(_ context.Context, _ resource.MetadataRequest, r
 }
 
 func taskTemplateNestedAttributes() map[string]schema.Attribute {
-	return map[string]schema.Attribute{
+	return templateSourceNestedAttributes(map[string]schema.Attribute{
 		"template_id": schema.StringAttribute{Optional: true, Description: "Template id."},
 		"name":        schema.StringAttribute{Required: true, Description: "Template name."},
 		"path":        schema.StringAttribute{Optional: true, Description: "Template path."},
@@ -42,7 +43,7 @@ func taskTemplateNestedAttributes() map[string]schema.Attribute {
 			Optional:    true,
 			Description: "Template meta (string map).",
 		},
-	}
+	})
 }
 
 func taskClaimNestedAttributes() map[string]schema.Attribute {
@@ -122,6 +123,20 @@ func (r *taskResource) ImportState(ctx context.Context, req resource.ImportState
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
 }
 
+// ModifyPlan plans contents_sha256 of the inline templates and checks the prerequisite tasks.
+func (r *taskResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	modifyPlanTemplateHashes(ctx, req, resp, path.Root("templates"))
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var prereqs types.List
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("prerequisites"), &prereqs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateReferenceList(ctx, r.client, "tasks", prereqs, path.Root("prerequisites"), &resp.Diagnostics)
+}
+
 func templateInfoType() types.ObjectType {
 	return types.ObjectType{AttrTypes: map[string]attr.Type{
 		"template_id": types.StringType,
@@ -130,6 +145,9 @@ func templateInfoType() types.ObjectType {
 		"contents":    types.StringType,
 		"link":        types.StringType,
 		"meta":        types.MapType{ElemType: types.StringType},
+
+		"source_file":     types.StringType,
+		"contents_sha256": types.StringType,
 	}}
 }
 
@@ -153,15 +171,15 @@ func (r *taskResource) expandTask(ctx context.Context, m *taskResourceModel, dia
 	if diags.HasError() {
 		return nil
 	}
-	task.Templates = r.expandTaskTemplates(ctx, m.Templates, diags)
-	task.ExtraClaims = r.expandClaims(ctx, m.ExtraClaims, diags)
+	task.Templates = expandTaskTemplates(ctx, m.Templates, diags)
+	task.ExtraClaims = expandClaims(ctx, m.ExtraClaims, diags)
 	if diags.HasError() {
 		return nil
 	}
 	return &task
 }
 
-func (r *taskResource) expandTaskTemplates(ctx context.Context, l types.List, diags *diag.Diagnostics) []models.TemplateInfo {
+func expandTaskTemplates(ctx context.Context, l types.List, diags *diag.Diagnostics) []models.TemplateInfo {
 	if l.IsNull() || l.IsUnknown() {
 		return nil
 	}
@@ -170,7 +188,7 @@ func (r *taskResource) expandTaskTemplates(ctx context.Context, l types.List, di
 		return nil
 	}
 	out := make([]models.TemplateInfo, 0, len(els))
-	for _, el := range els {
+	for i, el := range els {
 		o, ok := el.(types.Object)
 		if !ok {
 			diags.AddError("Invalid templates element", "expected object")
@@ -188,7 +206,7 @@ func (r *taskResource) expandTaskTemplates(ctx context.Context, l types.List, di
 			ID:       objectAttrString(o, "template_id"),
 			Name:     objectAttrString(o, "name"),
 			Path:     objectAttrString(o, "path"),
-			Contents: objectAttrString(o, "contents"),
+			Contents: templateBody(priorObjString(o, "contents"), priorObjString(o, "source_file"), path.Root("templates").AtListIndex(i), diags),
 			Link:     objectAttrString(o, "link"),
 			Meta:     meta,
 		})
@@ -196,7 +214,7 @@ func (r *taskResource) expandTaskTemplates(ctx context.Context, l types.List, di
 	return out
 }
 
-func (r *taskResource) expandClaims(ctx context.Context, l types.List, diags *diag.Diagnostics) []*models.Claim {
+func expandClaims(ctx context.Context, l types.List, diags *diag.Diagnostics) []*models.Claim {
 	if l.IsNull() || l.IsUnknown() {
 		return nil
 	}
@@ -220,7 +238,7 @@ func (r *taskResource) expandClaims(ctx context.Context, l types.List, diags *di
 	return out
 }
 
-func (r *taskResource) flattenTaskTemplatesMerged(ctx context.Context, prior types.List, api []models.TemplateInfo, diags *diag.Diagnostics) types.List {
+func flattenTaskTemplatesMerged(ctx context.Context, prior types.List, api []models.TemplateInfo, diags *diag.Diagnostics) types.List {
 	if len(api) == 0 {
 		if prior.IsNull() || prior.IsUnknown() {
 			return types.ListNull(templateInfoType())
@@ -250,9 +268,12 @@ func (r *taskResource) flattenTaskTemplatesMerged(ctx context.Context, prior typ
 			"template_id": mergeOptString(priorObjString(pObj, "template_id"), ti.ID),
 			"name":        types.StringValue(ti.Name),
 			"path":        mergeOptString(priorObjString(pObj, "path"), ti.Path),
-			"contents":    mergeOptString(priorObjString(pObj, "contents"), ti.Contents),
+			"contents":    flattenTemplateContents(priorObjString(pObj, "contents"), priorObjString(pObj, "source_file"), ti.Contents),
 			"link":        mergeOptString(priorObjString(pObj, "link"), ti.Link),
 			"meta":        metaVal,
+
+			"source_file":     priorObjString(pObj, "source_file"),
+			"contents_sha256": contentsSHA256(ti.Contents),
 		}
 		obj, d := types.ObjectValue(templateInfoType().AttrTypes, attrs)
 		diags.Append(d...)
@@ -265,7 +286,7 @@ func (r *taskResource) flattenTaskTemplatesMerged(ctx context.Context, prior typ
 	return types.ListValueMust(templateInfoType(), elems)
 }
 
-func (r *taskResource) flattenTaskClaimsMerged(ctx context.Context, prior types.List, api []*models.Claim, diags *diag.Diagnostics) types.List {
+func flattenTaskClaimsMerged(ctx context.Context, prior types.List, api []*models.Claim, diags *diag.Diagnostics) types.List {
 	if len(api) == 0 {
 		if prior.IsNull() || prior.IsUnknown() {
 			return types.ListNull(claimType())
@@ -309,8 +330,8 @@ func (r *taskResource) flattenTask(ctx context.Context, task *models.Task, m *ta
 	m.OptionalParams = mergeOptStringList(ctx, m.OptionalParams, task.OptionalParams, diags)
 	m.ExtraRoles = mergeOptStringList(ctx, m.ExtraRoles, task.ExtraRoles, diags)
 	m.Prerequisites = mergeOptStringList(ctx, m.Prerequisites, task.Prerequisites, diags)
-	m.Templates = r.flattenTaskTemplatesMerged(ctx, m.Templates, task.Templates, diags)
-	m.ExtraClaims = r.flattenTaskClaimsMerged(ctx, m.ExtraClaims, task.ExtraClaims, diags)
+	m.Templates = flattenTaskTemplatesMerged(ctx, m.Templates, task.Templates, diags)
+	m.ExtraClaims = flattenTaskClaimsMerged(ctx, m.ExtraClaims, task.ExtraClaims, diags)
 }
 
 func (r *taskResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -326,11 +347,11 @@ func (r *taskResource) Create(ctx context.Context, req resource.CreateRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(task); err != nil {
+	if err := r.client.createModel(ctx, task); err != nil {
 		resp.Diagnostics.AddError("Create task failed", err.Error())
 		return
 	}
-	to, err := r.client.session.GetModel("tasks", plan.Name.ValueString())
+	to, err := r.client.getModel(ctx, "tasks", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read task after create failed", err.Error())
 		return
@@ -348,7 +369,7 @@ func (r *taskResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	to, err := r.client.session.GetModel("tasks", state.Name.ValueString())
+	to, err := r.client.getModel(ctx, "tasks", state.Name.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -357,6 +378,7 @@ func (r *taskResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		resp.Diagnostics.AddError("Read task failed", err.Error())
 		return
 	}
+	warnIfContentOwned(to, &resp.Diagnostics)
 	r.flattenTask(ctx, to.(*models.Task), &state, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -374,11 +396,11 @@ func (r *taskResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(task); err != nil {
+	if err := r.client.putModel(ctx, task); err != nil {
 		resp.Diagnostics.AddError("Update task failed", err.Error())
 		return
 	}
-	to, err := r.client.session.GetModel("tasks", plan.Name.ValueString())
+	to, err := r.client.getModel(ctx, "tasks", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read task after update failed", err.Error())
 		return
@@ -396,7 +418,7 @@ func (r *taskResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("tasks", state.Name.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "tasks", state.Name.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete task failed", err.Error())
 	}
 }
@@ -1,6 +1,7 @@
 package drpv4
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -107,6 +108,23 @@ func TestAccTaskResource(t *testing.T) {
 					resource.TestCheckResourceAttr("drp_task.test", "optional_params.#", "1"),
 				),
 			},
+			{
+				Config: `
+					resource "drp_task" "test" {
+						name = "test"
+
+						templates = [{
+							name = "test"
+							contents = <<-EOF
+							#!/bin/bash
+							echo "{{ .Param "test" }"
+							EOF
+							path = "/test.sh"
+						}]
+					}
+				`,
+				ExpectError: regexp.MustCompile(`line 2: unexpected "}" in operand`),
+			},
 		},
 	})
 }
@@ -4,18 +4,22 @@ import (
 	"context"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/api"
 	"gitlab.com/rackn/provision/v4/models"
 )
 
 var _ resource.Resource = (*templateResource)(nil)
 var _ resource.ResourceWithImportState = (*templateResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*templateResource)(nil)
 
 type templateResource struct {
 	client *Config
@@ -56,6 +60,19 @@ func (r *templateResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 				Description:         "Template contents.",
 				MarkdownDescription: "Template contents.",
 			},
+			"source_file": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Local file to read the template contents from, instead of contents. Only the hash of the file is kept in state.",
+				MarkdownDescription: "Local file to read the template contents from, instead of `contents`. Only the hash of the file is kept in state.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("contents")),
+				},
+			},
+			"contents_sha256": schema.StringAttribute{
+				Computed:            true,
+				Description:         "SHA-256 of the template contents on the server.",
+				MarkdownDescription: "SHA-256 of the template contents on the server.",
+			},
 			"start_delimiter": schema.StringAttribute{
 				Optional:            true,
 				Description:         "Template start delimiter.",
@@ -78,6 +95,8 @@ type templateResourceModel struct {
 	TemplateID     types.String `tfsdk:"template_id"`
 	Description    types.String `tfsdk:"description"`
 	Contents       types.String `tfsdk:"contents"`
+	SourceFile     types.String `tfsdk:"source_file"`
+	ContentsSHA256 types.String `tfsdk:"contents_sha256"`
 	StartDelimiter types.String `tfsdk:"start_delimiter"`
 	EndDelimiter   types.String `tfsdk:"end_delimiter"`
 }
@@ -86,8 +105,10 @@ func (r *templateResource) ImportState(ctx context.Context, req resource.ImportS
 	resource.ImportStatePassthroughID(ctx, path.Root("template_id"), req, resp)
 }
 
-func (r *templateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	if r.client == nil {
+// ModifyPlan checks the template syntax and plans contents_sha256 from contents or
+// source_file, so an edited source file shows up as a hash change.
+func (r *templateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
 		return
 	}
 	var plan templateResourceModel
@@ -95,15 +116,41 @@ func (r *templateResource) Create(ctx context.Context, req resource.CreateReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	if plan.StartDelimiter.IsUnknown() || plan.EndDelimiter.IsUnknown() {
+		return
+	}
+	sum := planTemplateContents(plan.Contents, plan.SourceFile, plan.StartDelimiter.ValueString(), plan.EndDelimiter.ValueString(), path.Empty(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("contents_sha256"), sum)...)
+}
 
-	template := models.Template{
-		ID: plan.TemplateID.ValueString(),
+func (r *templateResource) expandTemplate(m *templateResourceModel, diags *diag.Diagnostics) models.Template {
+	return models.Template{
+		ID: m.TemplateID.ValueString(),
 		DescData: models.DescData{
-			Description: plan.Description.ValueString(),
+			Description: m.Description.ValueString(),
 		},
-		Contents:       plan.Contents.ValueString(),
-		StartDelimiter: plan.StartDelimiter.ValueString(),
-		EndDelimiter:   plan.EndDelimiter.ValueString(),
+		Contents:       templateBody(m.Contents, m.SourceFile, path.Empty(), diags),
+		StartDelimiter: m.StartDelimiter.ValueString(),
+		EndDelimiter:   m.EndDelimiter.ValueString(),
+	}
+}
+
+func (r *templateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan templateResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	template := r.expandTemplate(&plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 	template.Validate()
 	if template.Error() != "" {
@@ -111,8 +158,9 @@ func (r *templateResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
-	reqAPI := r.client.session.Req().Post(template).UrlFor("templates")
-	if err := reqAPI.Do(&template); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Post(template).UrlFor("templates")
+	}, &template); err != nil {
 		resp.Diagnostics.AddError("Create template failed", err.Error())
 		return
 	}
@@ -134,7 +182,7 @@ func (r *templateResource) Read(ctx context.Context, req resource.ReadRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	to, err := r.client.session.GetModel("templates", state.TemplateID.ValueString())
+	to, err := r.client.getModel(ctx, "templates", state.TemplateID.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -143,6 +191,7 @@ func (r *templateResource) Read(ctx context.Context, req resource.ReadRequest, r
 		resp.Diagnostics.AddError("Read template failed", err.Error())
 		return
 	}
+	warnIfContentOwned(to, &resp.Diagnostics)
 	r.flattenTemplate(ctx, to.(*models.Template), &state, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -150,7 +199,8 @@ func (r *templateResource) Read(ctx context.Context, req resource.ReadRequest, r
 func (r *templateResource) flattenTemplate(_ context.Context, t *models.Template, m *templateResourceModel, _ *diag.Diagnostics) {
 	m.TemplateID = types.StringValue(t.ID)
 	m.Description = types.StringValue(t.Description)
-	m.Contents = types.StringValue(t.Contents)
+	m.Contents = flattenTemplateContents(m.Contents, m.SourceFile, t.Contents)
+	m.ContentsSHA256 = contentsSHA256(t.Contents)
 	m.StartDelimiter = types.StringValue(t.StartDelimiter)
 	m.EndDelimiter = types.StringValue(t.EndDelimiter)
 }
@@ -165,14 +215,9 @@ func (r *templateResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
-	template := models.Template{
-		ID: plan.TemplateID.ValueString(),
-		DescData: models.DescData{
-			Description: plan.Description.ValueString(),
-		},
-		Contents:       plan.Contents.ValueString(),
-		StartDelimiter: plan.StartDelimiter.ValueString(),
-		EndDelimiter:   plan.EndDelimiter.ValueString(),
+	template := r.expandTemplate(&plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 	template.Validate()
 	if template.Error() != "" {
@@ -181,13 +226,14 @@ func (r *templateResource) Update(ctx context.Context, req resource.UpdateReques
 	}
 
 	var putResult templatePutResult
-	reqAPI := r.client.session.Req().Put(template).UrlFor("templates", plan.TemplateID.ValueString())
-	if err := reqAPI.Do(&putResult); err != nil {
+	if err := r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Put(template).UrlFor("templates", plan.TemplateID.ValueString())
+	}, &putResult); err != nil {
 		resp.Diagnostics.AddError("Update template failed", err.Error())
 		return
 	}
 
-	to, err := r.client.session.GetModel("templates", plan.TemplateID.ValueString())
+	to, err := r.client.getModel(ctx, "templates", plan.TemplateID.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read template after update failed", err.Error())
 		return
@@ -205,7 +251,7 @@ func (r *templateResource) Delete(ctx context.Context, req resource.DeleteReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("templates", state.TemplateID.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "templates", state.TemplateID.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete template failed", err.Error())
 	}
 }
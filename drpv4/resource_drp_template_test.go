@@ -2,6 +2,8 @@ package drpv4
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
@@ -45,6 +47,7 @@ func TestAccTemplateResource(t *testing.T) {
 					resource.TestCheckResourceAttr("drp_template.test", "template_id", "test"),
 					resource.TestCheckResourceAttr("drp_template.test", "description", "test"),
 					resource.TestCheckResourceAttr("drp_template.test", "contents", "test"),
+					resource.TestCheckResourceAttr("drp_template.test", "contents_sha256", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"),
 				),
 			},
 			{
@@ -61,6 +64,17 @@ func TestAccTemplateResource(t *testing.T) {
 					resource.TestCheckResourceAttr("drp_template.test", "end_delimiter", "]]"),
 				),
 			},
+			{
+				Config: testAccTemplateResourceConfig(TemplateResource{
+					ResourceName: "test",
+					ID:           "test",
+					Description:  "test",
+					Contents:     "[[ nosuchfunc ]]",
+					StartDelim:   "[[",
+					EndDelim:     "]]",
+				}),
+				ExpectError: regexp.MustCompile(`line 1: function "nosuchfunc" not defined`),
+			},
 			{
 				Config: testAccTemplateResourceConfig(TemplateResource{
 					ResourceName: "test",
@@ -73,3 +87,41 @@ func TestAccTemplateResource(t *testing.T) {
 		},
 	})
 }
+
+func TestAccTemplateResourceSourceFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.sh")
+	writeFile := func(contents string) func() {
+		return func() {
+			if err := os.WriteFile(file, []byte(contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	config := fmt.Sprintf(`
+		resource "drp_template" "test" {
+			template_id = "test-source-file"
+			source_file = %q
+		}
+	`, file)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				PreConfig: writeFile("test"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("drp_template.test", "contents"),
+					resource.TestCheckResourceAttr("drp_template.test", "contents_sha256", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"),
+				),
+			},
+			{
+				PreConfig: writeFile("test2"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_template.test", "contents_sha256", "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"),
+				),
+			},
+		},
+	})
+}
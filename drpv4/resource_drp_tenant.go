@@ -0,0 +1,202 @@
+package drpv4
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*tenantResource)(nil)
+var _ resource.ResourceWithImportState = (*tenantResource)(nil)
+
+type tenantResource struct {
+	client *Config
+}
+
+func NewTenantResource() resource.Resource {
+	return &tenantResource{}
+}
+
+func (r *tenantResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_tenant"
+}
+
+func (r *tenantResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Manages a tenant. User membership is managed through drp_user.tenant.",
+		MarkdownDescription: "Manages a tenant. User membership is managed through `drp_user.tenant`.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "Tenant name.",
+				MarkdownDescription: "Tenant name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"description": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Tenant description.",
+				MarkdownDescription: "Tenant description.",
+			},
+			"documentation": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Tenant documentation.",
+				MarkdownDescription: "Tenant documentation.",
+			},
+			"members": schema.MapAttribute{
+				ElementType:         types.ListType{ElemType: types.StringType},
+				Optional:            true,
+				Description:         "Keys of the objects visible to the tenant, by object type (e.g. machines, profiles).",
+				MarkdownDescription: "Keys of the objects visible to the tenant, by object type (e.g. `machines`, `profiles`).",
+			},
+		},
+	}
+}
+
+func (r *tenantResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type tenantResourceModel struct {
+	Name          types.String `tfsdk:"name"`
+	Description   types.String `tfsdk:"description"`
+	Documentation types.String `tfsdk:"documentation"`
+	Members       types.Map    `tfsdk:"members"`
+}
+
+func (r *tenantResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
+// expandTenant applies the planned fields to t, leaving the server-managed user list alone.
+func (r *tenantResource) expandTenant(ctx context.Context, m *tenantResourceModel, t *models.Tenant, diags *diag.Diagnostics) {
+	t.Name = m.Name.ValueString()
+	t.DocData = newDocData(m.Description.ValueString(), m.Documentation.ValueString())
+	t.Members = map[string][]string{}
+	if !m.Members.IsNull() && !m.Members.IsUnknown() {
+		diags.Append(m.Members.ElementsAs(ctx, &t.Members, false)...)
+	}
+}
+
+func (r *tenantResource) flattenTenant(ctx context.Context, t *models.Tenant, m *tenantResourceModel, diags *diag.Diagnostics) {
+	m.Name = types.StringValue(t.Name)
+	m.Description = mergeOptString(m.Description, t.Description)
+	m.Documentation = mergeOptString(m.Documentation, t.Documentation)
+	if len(t.Members) == 0 {
+		// Keep members = {} from the configuration rather than reading it back as null.
+		if m.Members.IsNull() || m.Members.IsUnknown() {
+			m.Members = types.MapNull(types.ListType{ElemType: types.StringType})
+		} else {
+			m.Members = types.MapValueMust(types.ListType{ElemType: types.StringType}, map[string]attr.Value{})
+		}
+		return
+	}
+	members, d := types.MapValueFrom(ctx, types.ListType{ElemType: types.StringType}, t.Members)
+	diags.Append(d...)
+	m.Members = members
+}
+
+func (r *tenantResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan tenantResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tenant := &models.Tenant{}
+	r.expandTenant(ctx, &plan, tenant, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.createModel(ctx, tenant); err != nil {
+		resp.Diagnostics.AddError("Create tenant failed", err.Error())
+		return
+	}
+	got, err := r.client.getModel(ctx, "tenants", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read tenant after create failed", err.Error())
+		return
+	}
+	r.flattenTenant(ctx, got.(*models.Tenant), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *tenantResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state tenantResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	res, err := r.client.getModel(ctx, "tenants", state.Name.ValueString())
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "Not Found") {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read tenant failed", err.Error())
+		return
+	}
+	r.flattenTenant(ctx, res.(*models.Tenant), &state, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *tenantResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan tenantResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	cur, err := r.client.getModel(ctx, "tenants", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read tenant before update failed", err.Error())
+		return
+	}
+	tenant := cur.(*models.Tenant)
+	r.expandTenant(ctx, &plan, tenant, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.putModel(ctx, tenant); err != nil {
+		resp.Diagnostics.AddError("Update tenant failed", err.Error())
+		return
+	}
+	got, err := r.client.getModel(ctx, "tenants", plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read tenant after update failed", err.Error())
+		return
+	}
+	r.flattenTenant(ctx, got.(*models.Tenant), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *tenantResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state tenantResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.deleteModel(ctx, "tenants", state.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddError("Delete tenant failed", err.Error())
+	}
+}
@@ -0,0 +1,59 @@
+package drpv4
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccTenantResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_tenant" "test" {
+						name = "test-tenant"
+						description = "test"
+						members = {
+							profiles = ["global"]
+						}
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_tenant.test", "name", "test-tenant"),
+					resource.TestCheckResourceAttr("drp_tenant.test", "members.profiles.#", "1"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_tenant" "test" {
+						name = "test-tenant"
+						description = "test"
+						members = {
+							profiles = ["global"]
+							params = ["test"]
+						}
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_tenant.test", "members.%", "2"),
+					resource.TestCheckResourceAttr("drp_tenant.test", "members.params.0", "test"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_tenant" "test" {
+						name = "test-tenant"
+						description = "test"
+						members = {}
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_tenant.test", "members.%", "0"),
+				),
+			},
+		},
+	})
+}
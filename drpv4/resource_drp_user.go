@@ -0,0 +1,357 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gitlab.com/rackn/jp"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*userResource)(nil)
+var _ resource.ResourceWithImportState = (*userResource)(nil)
+
+type userResource struct {
+	client *Config
+}
+
+func NewUserResource() resource.Resource {
+	return &userResource{}
+}
+
+func (r *userResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_user"
+}
+
+func (r *userResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Manages a user that can log in to DRP.",
+		MarkdownDescription: "Manages a user that can log in to DRP.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:            true,
+				Description:         "User name.",
+				MarkdownDescription: "User name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"description": schema.StringAttribute{
+				Optional:            true,
+				Description:         "User description.",
+				MarkdownDescription: "User description.",
+			},
+			"password": schema.StringAttribute{
+				Optional:            true,
+				Sensitive:           true,
+				Description:         "Clear text password. DRP stores only its hash, so changes made outside Terraform are not detected.",
+				MarkdownDescription: "Clear text password. DRP stores only its hash, so changes made outside Terraform are not detected.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("password_hash")),
+				},
+			},
+			"password_hash": schema.StringAttribute{
+				Optional:            true,
+				Sensitive:           true,
+				Description:         "Pre-computed scrypt password hash, as produced by drpcli users password.",
+				MarkdownDescription: "Pre-computed scrypt password hash, as produced by `drpcli users password`.",
+			},
+			"roles": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Roles granted to the user.",
+				MarkdownDescription: "Roles granted to the user.",
+			},
+			"tenant": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Tenant the user belongs to. A user can be a member of at most one tenant.",
+				MarkdownDescription: "Tenant the user belongs to. A user can be a member of at most one tenant.",
+			},
+			"secret_version": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Any value; changing it regenerates the user secret, invalidating every token issued to the user.",
+				MarkdownDescription: "Any value; changing it regenerates the user secret, invalidating every token issued to the user.",
+			},
+		},
+	}
+}
+
+func (r *userResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type userResourceModel struct {
+	Name          types.String `tfsdk:"name"`
+	Description   types.String `tfsdk:"description"`
+	Password      types.String `tfsdk:"password"`
+	PasswordHash  types.String `tfsdk:"password_hash"`
+	Roles         types.List   `tfsdk:"roles"`
+	Tenant        types.String `tfsdk:"tenant"`
+	SecretVersion types.String `tfsdk:"secret_version"`
+}
+
+func (r *userResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
+// flattenUser refreshes the fields DRP reports back. Password, hash and secret version
+// are write-only and keep their prior values.
+func (r *userResource) flattenUser(ctx context.Context, u *models.User, tenant string, m *userResourceModel, diags *diag.Diagnostics) {
+	m.Name = types.StringValue(u.Name)
+	m.Description = mergeOptString(m.Description, u.Description)
+	m.Roles = mergeOptStringList(ctx, m.Roles, u.Roles, diags)
+	if tenant == "" {
+		m.Tenant = types.StringNull()
+	} else {
+		m.Tenant = types.StringValue(tenant)
+	}
+}
+
+func (r *userResource) setPassword(ctx context.Context, name, password string) error {
+	return r.client.do(ctx, func() *api.R {
+		return r.client.session.Req().Put(&models.UserPassword{Password: password}).UrlFor("users", name, "password")
+	}, nil)
+}
+
+// userTenant returns the tenant whose user list contains name, or "" when there is none.
+func (r *userResource) userTenant(ctx context.Context, name string) (string, error) {
+	tenants, err := r.client.listModel(ctx, "tenants")
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tenants {
+		if tenant := t.(*models.Tenant); slices.Contains(tenant.Users, name) {
+			return tenant.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// addTenantUser appends name to the tenant's user list.
+func (r *userResource) addTenantUser(ctx context.Context, tenant, name string) error {
+	res, err := r.client.getModel(ctx, "tenants", tenant)
+	if err != nil {
+		return err
+	}
+	t := res.(*models.Tenant)
+	if slices.Contains(t.Users, name) {
+		return nil
+	}
+	var patcher jp.Patcher
+	if t.Users == nil {
+		patcher.Add(jp.Ptr("/Users"), []string{name})
+	} else {
+		patcher.Add(jp.Ptr("/Users/-"), name)
+	}
+	patch, err := patcher.Patch()
+	if err != nil {
+		return fmt.Errorf("build patch: %w", err)
+	}
+	_, err = r.client.patchModel(ctx, "tenants", tenant, patch)
+	return err
+}
+
+// removeTenantUser drops name from the tenant's user list. The test op keeps a concurrent
+// change to the list from removing the wrong entry.
+func (r *userResource) removeTenantUser(ctx context.Context, tenant, name string) error {
+	res, err := r.client.getModel(ctx, "tenants", tenant)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	idx := slices.Index(res.(*models.Tenant).Users, name)
+	if idx < 0 {
+		return nil
+	}
+	var patcher jp.Patcher
+	ptr := jp.PtrTo("Users", strconv.Itoa(idx))
+	patcher.Test(ptr, name)
+	patcher.Remove(ptr)
+	patch, err := patcher.Patch()
+	if err != nil {
+		return fmt.Errorf("build patch: %w", err)
+	}
+	_, err = r.client.patchModel(ctx, "tenants", tenant, patch)
+	return err
+}
+
+func (r *userResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan userResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	name := plan.Name.ValueString()
+	user := &models.User{
+		Name:  name,
+		Roles: diagListToStrings(ctx, plan.Roles, &resp.Diagnostics),
+	}
+	user.Description = plan.Description.ValueString()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if h := plan.PasswordHash.ValueString(); h != "" {
+		user.PasswordHash = []byte(h)
+	}
+	if !plan.SecretVersion.IsNull() {
+		user.Secret = models.RandString(16)
+	}
+	if err := r.client.createModel(ctx, user); err != nil {
+		resp.Diagnostics.AddError("Create user failed", err.Error())
+		return
+	}
+	if pw := plan.Password.ValueString(); pw != "" {
+		if err := r.setPassword(ctx, name, pw); err != nil {
+			resp.Diagnostics.AddError("Set user password failed", err.Error())
+			return
+		}
+	}
+	if t := plan.Tenant.ValueString(); t != "" {
+		if err := r.addTenantUser(ctx, t, name); err != nil {
+			resp.Diagnostics.AddError("Add user to tenant failed", fmt.Sprintf("tenant %s: %s", t, err))
+			return
+		}
+	}
+	got, err := r.client.getModel(ctx, "users", name)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user after create failed", err.Error())
+		return
+	}
+	r.flattenUser(ctx, got.(*models.User), plan.Tenant.ValueString(), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *userResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state userResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	res, err := r.client.getModel(ctx, "users", state.Name.ValueString())
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "Not Found") {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read user failed", err.Error())
+		return
+	}
+	tenant, err := r.userTenant(ctx, state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Read user tenant failed", err.Error())
+		return
+	}
+	warnIfContentOwned(res, &resp.Diagnostics)
+	r.flattenUser(ctx, res.(*models.User), tenant, &state, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *userResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan, state userResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	name := plan.Name.ValueString()
+
+	var patcher jp.Patcher
+	patcher.Add(jp.Ptr("/Description"), plan.Description.ValueString())
+	roles := diagListToStrings(ctx, plan.Roles, &resp.Diagnostics)
+	if roles == nil {
+		roles = []string{}
+	}
+	patcher.Add(jp.Ptr("/Roles"), roles)
+	if h := plan.PasswordHash.ValueString(); h != "" && !plan.PasswordHash.Equal(state.PasswordHash) {
+		patcher.Add(jp.Ptr("/PasswordHash"), []byte(h))
+	}
+	if !plan.SecretVersion.Equal(state.SecretVersion) {
+		patcher.Add(jp.Ptr("/Secret"), models.RandString(16))
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	patch, err := patcher.Patch()
+	if err != nil {
+		resp.Diagnostics.AddError("Update user failed", fmt.Sprintf("build patch: %s", err))
+		return
+	}
+	if _, err := r.client.patchModel(ctx, "users", name, patch); err != nil {
+		resp.Diagnostics.AddError("Update user failed", err.Error())
+		return
+	}
+	if pw := plan.Password.ValueString(); pw != "" && !plan.Password.Equal(state.Password) {
+		if err := r.setPassword(ctx, name, pw); err != nil {
+			resp.Diagnostics.AddError("Set user password failed", err.Error())
+			return
+		}
+	}
+
+	if from, to := state.Tenant.ValueString(), plan.Tenant.ValueString(); from != to {
+		if from != "" {
+			if err := r.removeTenantUser(ctx, from, name); err != nil {
+				resp.Diagnostics.AddError("Remove user from tenant failed", fmt.Sprintf("tenant %s: %s", from, err))
+				return
+			}
+		}
+		if to != "" {
+			if err := r.addTenantUser(ctx, to, name); err != nil {
+				resp.Diagnostics.AddError("Add user to tenant failed", fmt.Sprintf("tenant %s: %s", to, err))
+				return
+			}
+		}
+	}
+
+	got, err := r.client.getModel(ctx, "users", name)
+	if err != nil {
+		resp.Diagnostics.AddError("Read user after update failed", err.Error())
+		return
+	}
+	r.flattenUser(ctx, got.(*models.User), plan.Tenant.ValueString(), &plan, &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *userResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state userResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	name := state.Name.ValueString()
+	if t := state.Tenant.ValueString(); t != "" {
+		if err := r.removeTenantUser(ctx, t, name); err != nil {
+			resp.Diagnostics.AddError("Remove user from tenant failed", fmt.Sprintf("tenant %s: %s", t, err))
+			return
+		}
+	}
+	if _, err := r.client.deleteModel(ctx, "users", name); err != nil {
+		resp.Diagnostics.AddError("Delete user failed", err.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package drpv4
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccUserResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_tenant" "test" {
+						name = "test-user-tenant"
+					}
+
+					resource "drp_user" "test" {
+						name = "test-user"
+						description = "test"
+						password = "test-password"
+						roles = ["superuser"]
+						tenant = drp_tenant.test.name
+						secret_version = "1"
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_user.test", "name", "test-user"),
+					resource.TestCheckResourceAttr("drp_user.test", "roles.#", "1"),
+					resource.TestCheckResourceAttr("drp_user.test", "tenant", "test-user-tenant"),
+				),
+			},
+			{
+				Config: `
+					resource "drp_tenant" "test" {
+						name = "test-user-tenant"
+					}
+
+					resource "drp_user" "test" {
+						name = "test-user"
+						description = "test"
+						password = "test-password-2"
+						roles = []
+						secret_version = "2"
+					}
+				`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_user.test", "roles.#", "0"),
+					resource.TestCheckNoResourceAttr("drp_user.test", "tenant"),
+					resource.TestCheckResourceAttr("drp_user.test", "secret_version", "2"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,285 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pborman/uuid"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*workOrderResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*workOrderResource)(nil)
+
+// workOrderResource queues a blueprint on a machine in work-order mode. Work orders cannot
+// be changed once created, so any change to what runs replaces it with a new one.
+type workOrderResource struct {
+	client *Config
+}
+
+func NewWorkOrderResource() resource.Resource {
+	return &workOrderResource{}
+}
+
+func (r *workOrderResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_work_order"
+}
+
+func (r *workOrderResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Runs a blueprint as a work order on a machine in work-order mode and optionally waits for it. Destroying the resource deletes the work order record.",
+		MarkdownDescription: "Runs a blueprint as a work order on a machine in work-order mode and optionally waits for it. Destroying the resource deletes the work order record.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Work order UUID.",
+				MarkdownDescription: "Work order UUID.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"machine": schema.StringAttribute{
+				Required:            true,
+				Description:         "UUID of the machine to run the work order on.",
+				MarkdownDescription: "UUID of the machine to run the work order on.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"blueprint": schema.StringAttribute{
+				Required:            true,
+				Description:         "Blueprint to run.",
+				MarkdownDescription: "Blueprint to run.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"params": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Work order params (values are strings; typed per param definition).",
+				MarkdownDescription: "Work order params (values are strings; typed per param definition).",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"profiles": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Profiles added to the work order.",
+				MarkdownDescription: "Profiles added to the work order.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"filter": schema.StringAttribute{
+				Optional:            true,
+				Description:         "List filter for the work order (WorkOrder.Filter).",
+				MarkdownDescription: "List filter for the work order (`WorkOrder.Filter`).",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"wait_for_state": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Wait after creation until the work order reaches this state (running or finished). A failed or cancelled work order is an error.",
+				MarkdownDescription: "Wait after creation until the work order reaches this state (`running` or `finished`). A failed or cancelled work order is an error.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("running", "finished"),
+				},
+			},
+			"wait_timeout": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("60m"),
+				Description:         "Max time string to wait for wait_for_state.",
+				MarkdownDescription: "Max time string to wait for `wait_for_state`.",
+			},
+			"state": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Digital Rebar WorkOrder.State (created, running, failed, finished or cancelled).",
+				MarkdownDescription: "Digital Rebar WorkOrder.State (created, running, failed, finished or cancelled).",
+			},
+			"status": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Digital Rebar WorkOrder.Status.",
+				MarkdownDescription: "Digital Rebar WorkOrder.Status.",
+			},
+			"current_job": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Last job run by the work order.",
+				MarkdownDescription: "Last job run by the work order.",
+			},
+		},
+	}
+}
+
+func (r *workOrderResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type workOrderResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	Machine      types.String `tfsdk:"machine"`
+	Blueprint    types.String `tfsdk:"blueprint"`
+	Params       types.Map    `tfsdk:"params"`
+	Profiles     types.List   `tfsdk:"profiles"`
+	Filter       types.String `tfsdk:"filter"`
+	WaitForState types.String `tfsdk:"wait_for_state"`
+	WaitTimeout  types.String `tfsdk:"wait_timeout"`
+	State        types.String `tfsdk:"state"`
+	Status       types.String `tfsdk:"status"`
+	CurrentJob   types.String `tfsdk:"current_job"`
+}
+
+// ModifyPlan validates params against the schemas of their param definitions and checks the
+// referenced blueprint and profiles.
+func (r *workOrderResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan workOrderResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateParamMap(ctx, r.client, plan.Params, path.Root("params"), &resp.Diagnostics)
+	validateReference(ctx, r.client, "blueprints", plan.Blueprint, path.Root("blueprint"), &resp.Diagnostics)
+	validateReferenceList(ctx, r.client, "profiles", plan.Profiles, path.Root("profiles"), &resp.Diagnostics)
+}
+
+func (r *workOrderResource) expandWorkOrder(ctx context.Context, m *workOrderResourceModel, diags *diag.Diagnostics) *models.WorkOrder {
+	machine := uuid.Parse(m.Machine.ValueString())
+	if machine == nil {
+		diags.AddAttributeError(path.Root("machine"), "Invalid machine", fmt.Sprintf("%q is not a machine UUID.", m.Machine.ValueString()))
+		return nil
+	}
+	params := convertParamMap(ctx, r.client, m.Params, path.Root("params"), diags)
+	if diags.HasError() {
+		return nil
+	}
+	return &models.WorkOrder{
+		Machine:   machine,
+		Blueprint: m.Blueprint.ValueString(),
+		Params:    params,
+		Profiles:  diagListToStrings(ctx, m.Profiles, diags),
+		Filter:    m.Filter.ValueString(),
+		State:     "created",
+	}
+}
+
+func flattenWorkOrder(w *models.WorkOrder, m *workOrderResourceModel) {
+	m.ID = types.StringValue(w.Uuid.String())
+	m.State = types.StringValue(w.State)
+	m.Status = types.StringValue(w.Status)
+	m.CurrentJob = types.StringValue(w.CurrentJob.String())
+}
+
+func (r *workOrderResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan workOrderResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var timeout time.Duration
+	waitState := plan.WaitForState.ValueString()
+	if waitState != "" {
+		d, err := time.ParseDuration(plan.WaitTimeout.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("wait_timeout"), "Invalid wait_timeout", err.Error())
+			return
+		}
+		timeout = d
+	}
+	wo := r.expandWorkOrder(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.createModel(ctx, wo); err != nil {
+		resp.Diagnostics.AddError("Create work order failed", err.Error())
+		return
+	}
+	id := wo.Uuid.String()
+	tflog.Debug(ctx, "created work order", map[string]interface{}{"uuid": id, "machine": plan.Machine.ValueString(), "blueprint": wo.Blueprint})
+
+	if waitState != "" {
+		// Keep the work order in state even when it fails so it is tainted and re-run.
+		w, err := waitForWorkOrder(ctx, r.client, id, waitState, timeout)
+		if err != nil {
+			resp.Diagnostics.AddError("Work order failed", err.Error())
+		}
+		if w != nil {
+			wo = w
+		}
+	}
+	flattenWorkOrder(wo, &plan)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workOrderResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		return
+	}
+	var state workOrderResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	res, err := r.client.getModel(ctx, "work_orders", state.ID.ValueString())
+	if err != nil {
+		if isNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Read work order failed", err.Error())
+		return
+	}
+	flattenWorkOrder(res.(*models.WorkOrder), &state)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update only records new wait settings; the work order itself cannot change.
+func (r *workOrderResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state workOrderResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = state.ID
+	plan.State = state.State
+	plan.Status = state.Status
+	plan.CurrentJob = state.CurrentJob
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workOrderResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		return
+	}
+	var state workOrderResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if _, err := r.client.deleteModel(ctx, "work_orders", state.ID.ValueString()); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Delete work order failed", err.Error())
+	}
+}
@@ -0,0 +1,41 @@
+package drpv4
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccWorkOrderResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_blueprint" "test" {
+						name = "test-work-order"
+						tasks = ["noop"]
+					}
+
+					resource "drp_work_order" "test" {
+						machine = "not-a-uuid"
+						blueprint = drp_blueprint.test.name
+					}
+				`,
+				ExpectError: regexp.MustCompile("Invalid machine"),
+			},
+			{
+				Config: `
+					resource "drp_work_order" "test" {
+						machine = "00000000-0000-0000-0000-000000000000"
+						blueprint = "noop"
+						wait_for_state = "done"
+					}
+				`,
+				ExpectError: regexp.MustCompile(`Attribute wait_for_state value must be one of`),
+			},
+		},
+	})
+}
@@ -16,6 +16,7 @@ import (
 
 var _ resource.Resource = (*workflowResource)(nil)
 var _ resource.ResourceWithImportState = (*workflowResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*workflowResource)(nil)
 
 type workflowResource struct {
 	client *Config
@@ -75,6 +76,19 @@ func (r *workflowResource) ImportState(ctx context.Context, req resource.ImportS
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
 }
 
+// ModifyPlan checks that the workflow stages exist.
+func (r *workflowResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var stages types.List
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("stages"), &stages)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	validateReferenceList(ctx, r.client, "stages", stages, path.Root("stages"), &resp.Diagnostics)
+}
+
 func (r *workflowResource) expandWorkflow(ctx context.Context, m *workflowResourceModel, diags *diag.Diagnostics) *models.Workflow {
 	stages := diagListToStrings(ctx, m.Stages, diags)
 	return &models.Workflow{
@@ -104,11 +118,11 @@ func (r *workflowResource) Create(ctx context.Context, req resource.CreateReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.CreateModel(wf); err != nil {
+	if err := r.client.createModel(ctx, wf); err != nil {
 		resp.Diagnostics.AddError("Create workflow failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("workflows", plan.Name.ValueString())
+	got, err := r.client.getModel(ctx, "workflows", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read workflow after create failed", err.Error())
 		return
@@ -126,7 +140,7 @@ func (r *workflowResource) Read(ctx context.Context, req resource.ReadRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	res, err := r.client.session.GetModel("workflows", state.Name.ValueString())
+	res, err := r.client.getModel(ctx, "workflows", state.Name.ValueString())
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "Not Found") {
 			resp.State.RemoveResource(ctx)
@@ -135,6 +149,7 @@ func (r *workflowResource) Read(ctx context.Context, req resource.ReadRequest, r
 		resp.Diagnostics.AddError("Read workflow failed", err.Error())
 		return
 	}
+	warnIfContentOwned(res, &resp.Diagnostics)
 	r.flattenWorkflow(ctx, res.(*models.Workflow), &state, &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -152,11 +167,11 @@ func (r *workflowResource) Update(ctx context.Context, req resource.UpdateReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if err := r.client.session.PutModel(wf); err != nil {
+	if err := r.client.putModel(ctx, wf); err != nil {
 		resp.Diagnostics.AddError("Update workflow failed", err.Error())
 		return
 	}
-	got, err := r.client.session.GetModel("workflows", plan.Name.ValueString())
+	got, err := r.client.getModel(ctx, "workflows", plan.Name.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Read workflow after update failed", err.Error())
 		return
@@ -174,7 +189,7 @@ func (r *workflowResource) Delete(ctx context.Context, req resource.DeleteReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if _, err := r.client.session.DeleteModel("workflows", state.Name.ValueString()); err != nil {
+	if _, err := r.client.deleteModel(ctx, "workflows", state.Name.ValueString()); err != nil {
 		resp.Diagnostics.AddError("Delete workflow failed", err.Error())
 	}
 }
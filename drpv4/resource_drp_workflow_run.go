@@ -0,0 +1,246 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/jp"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+var _ resource.Resource = (*workflowRunResource)(nil)
+
+// workflowRunResource runs a workflow on existing machines. It only acts on create; any
+// change to the machines, workflow or triggers replaces it, which runs the workflow again.
+type workflowRunResource struct {
+	client *Config
+}
+
+func NewWorkflowRunResource() resource.Resource {
+	return &workflowRunResource{}
+}
+
+func (r *workflowRunResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "drp_workflow_run"
+}
+
+func (r *workflowRunResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description:         "Runs a workflow on existing machines and optionally waits for it to complete. Destroying the resource does not change the machines.",
+		MarkdownDescription: "Runs a workflow on existing machines and optionally waits for it to complete. Destroying the resource does not change the machines.",
+		Attributes: map[string]schema.Attribute{
+			"machines": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Required:            true,
+				Description:         "UUIDs of the machines to run the workflow on.",
+				MarkdownDescription: "UUIDs of the machines to run the workflow on.",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"workflow": schema.StringAttribute{
+				Required:            true,
+				Description:         "Workflow to set on the machines.",
+				MarkdownDescription: "Workflow to set on the machines.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"reset_current_task": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+				Description:         "Reset Machine.CurrentTask so the workflow restarts from its first task. Machines already set to the workflow are always reset.",
+				MarkdownDescription: "Reset `Machine.CurrentTask` so the workflow restarts from its first task. Machines already set to the workflow are always reset.",
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Arbitrary values that run the workflow again when changed.",
+				MarkdownDescription: "Arbitrary values that run the workflow again when changed.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"wait_for_workflow_complete": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+				Description:         "Wait until Machine.WorkflowComplete is true on every machine.",
+				MarkdownDescription: "Wait until Machine.WorkflowComplete is true on every machine.",
+			},
+			"wait_timeout": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("60m"),
+				Description:         "Max time string to wait for the workflow on all machines.",
+				MarkdownDescription: "Max time string to wait for the workflow on all machines.",
+			},
+			"job_ids": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				Description:         "Last job of each machine, keyed by machine UUID.",
+				MarkdownDescription: "Last job of each machine, keyed by machine UUID.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"final_stages": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				Description:         "Stage of each machine once the run finished, keyed by machine UUID.",
+				MarkdownDescription: "Stage of each machine once the run finished, keyed by machine UUID.",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *workflowRunResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureResourceClient(req, resp)
+}
+
+type workflowRunResourceModel struct {
+	Machines         types.List   `tfsdk:"machines"`
+	Workflow         types.String `tfsdk:"workflow"`
+	ResetCurrentTask types.Bool   `tfsdk:"reset_current_task"`
+	Triggers         types.Map    `tfsdk:"triggers"`
+	WaitWorkflow     types.Bool   `tfsdk:"wait_for_workflow_complete"`
+	WaitTimeout      types.String `tfsdk:"wait_timeout"`
+	JobIDs           types.Map    `tfsdk:"job_ids"`
+	FinalStages      types.Map    `tfsdk:"final_stages"`
+}
+
+// startWorkflow sets the workflow on the machine and returns the job the machine last ran.
+// The current task is reset when asked, and always when the machine is already set to the
+// workflow, since setting the same workflow again does not run it again.
+func (r *workflowRunResource) startWorkflow(ctx context.Context, uuid, workflow string, reset bool) (string, error) {
+	mo, err := r.client.getModel(ctx, "machines", uuid)
+	if err != nil {
+		return "", err
+	}
+	m := mo.(*models.Machine)
+	var patcher jp.Patcher
+	patcher.Replace(jp.Ptr("/Workflow"), workflow)
+	if reset || m.Workflow == workflow {
+		patcher.Replace(jp.Ptr("/CurrentTask"), -1)
+	}
+	patch, err := patcher.Patch()
+	if err != nil {
+		return "", fmt.Errorf("build patch: %w", err)
+	}
+	_, err = r.client.patchModel(ctx, "machines", uuid, patch)
+	return m.CurrentJob.String(), err
+}
+
+func (r *workflowRunResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		return
+	}
+	var plan workflowRunResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	uuids := diagListToStrings(ctx, plan.Machines, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	timeout, err := time.ParseDuration(plan.WaitTimeout.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("wait_timeout"), "Invalid wait_timeout", err.Error())
+		return
+	}
+
+	workflow := plan.Workflow.ValueString()
+	priorJobs := make(map[string]string, len(uuids))
+	for _, uuid := range uuids {
+		job, err := r.startWorkflow(ctx, uuid, workflow, plan.ResetCurrentTask.ValueBool())
+		if err != nil {
+			resp.Diagnostics.AddError("Start workflow failed", fmt.Sprintf("machine %s: %s", uuid, err))
+			return
+		}
+		priorJobs[uuid] = job
+		tflog.Debug(ctx, "started workflow", map[string]interface{}{"uuid": uuid, "workflow": workflow, "prior_job": job})
+	}
+
+	// Every machine shares one deadline, so the whole run is bounded by wait_timeout.
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	jobs := make(map[string]string, len(uuids))
+	stages := make(map[string]string, len(uuids))
+	waiting := plan.WaitWorkflow.ValueBool()
+	for _, uuid := range uuids {
+		var m *models.Machine
+		if waiting {
+			// A failure of the job that ran before the workflow was started is not this run's.
+			opts := machineWaitOptions{WorkflowComplete: true, Timeout: timeout, IgnoreJob: priorJobs[uuid]}
+			m, err = waitForMachine(waitCtx, r.client, uuid, opts)
+			if err != nil {
+				// Keep what ran in state so the run is tainted and re-run on the next apply,
+				// and stop waiting on the remaining machines.
+				resp.Diagnostics.AddError("Workflow run failed", err.Error())
+				waiting = false
+			}
+		}
+		if m == nil {
+			mo, err := r.client.getModel(ctx, "machines", uuid)
+			if err != nil {
+				resp.Diagnostics.AddError("Read machine failed", fmt.Sprintf("machine %s: %s", uuid, err))
+				continue
+			}
+			m = mo.(*models.Machine)
+		}
+		jobs[uuid] = m.CurrentJob.String()
+		stages[uuid] = m.Stage
+	}
+
+	jv, d := types.MapValueFrom(ctx, types.StringType, jobs)
+	resp.Diagnostics.Append(d...)
+	sv, d := types.MapValueFrom(ctx, types.StringType, stages)
+	resp.Diagnostics.Append(d...)
+	plan.JobIDs = jv
+	plan.FinalStages = sv
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read keeps the recorded run; the machines have moved on since and are not refreshed.
+func (r *workflowRunResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state workflowRunResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update only records new wait settings; they take effect on the next run.
+func (r *workflowRunResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan workflowRunResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workflowRunResource) Delete(_ context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+}
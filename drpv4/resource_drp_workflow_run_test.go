@@ -0,0 +1,178 @@
+package drpv4
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/pborman/uuid"
+	"gitlab.com/rackn/jp"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+func TestAccWorkflowRunResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: `
+					resource "drp_workflow_run" "test" {
+						machines = ["00000000-0000-0000-0000-000000000000"]
+						workflow = "noop"
+						wait_timeout = "1m"
+						triggers = {
+							run = "1"
+						}
+					}
+				`,
+				ExpectError: regexp.MustCompile("Start workflow failed"),
+			},
+		},
+	})
+}
+
+func TestAccWorkflowRunResourceMachines(t *testing.T) {
+	name := fmt.Sprintf("tf-wfrun-%s", accRandomSuffix(10))
+	var c *Config
+	config := func(run string) string {
+		return fmt.Sprintf(`
+			resource "drp_task" "test" {
+				name = "%[1]s"
+				templates = [{
+					name     = "run"
+					contents = "#!/bin/bash\necho run"
+				}]
+			}
+
+			resource "drp_stage" "test" {
+				name  = "%[1]s"
+				tasks = [drp_task.test.name]
+			}
+
+			resource "drp_workflow" "test" {
+				name   = "%[1]s"
+				stages = [drp_stage.test.name]
+			}
+
+			data "drp_machines" "test" {
+				filters = ["Name=Re(^%[1]s-)"]
+				sort    = "Name"
+			}
+
+			resource "drp_workflow_run" "test" {
+				machines                   = data.drp_machines.test.machines[*].id
+				workflow                   = drp_workflow.test.name
+				wait_for_workflow_complete = false
+				triggers = {
+					run = "%[2]s"
+				}
+			}
+		`, name, run)
+	}
+	// checkRestarted checks that every machine is set to the workflow from its first task.
+	checkRestarted := func(s *terraform.State) error {
+		rs := s.RootModule().Resources["drp_workflow_run.test"]
+		if rs == nil {
+			return fmt.Errorf("drp_workflow_run.test not in state")
+		}
+		for k, v := range rs.Primary.Attributes {
+			id, ok := strings.CutPrefix(k, "final_stages.")
+			if !ok || id == "%" {
+				continue
+			}
+			mo, err := c.getModel(context.Background(), "machines", id)
+			if err != nil {
+				return err
+			}
+			m := mo.(*models.Machine)
+			if m.Workflow != name || m.CurrentTask != -1 {
+				return fmt.Errorf("machine %s has workflow %q, current task %d; want %q, -1", m.Name, m.Workflow, m.CurrentTask, name)
+			}
+			if v == "" {
+				return fmt.Errorf("final_stages.%s is empty", id)
+			}
+		}
+		return nil
+	}
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck: func() {
+			testAccPreCheck(t)
+			c = testAccClient(t)
+			testAccMachines(t, c, name, 2)
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config("1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("drp_workflow_run.test", "machines.#", "2"),
+					resource.TestCheckResourceAttr("drp_workflow_run.test", "job_ids.%", "2"),
+					resource.TestCheckResourceAttr("drp_workflow_run.test", "final_stages.%", "2"),
+					checkRestarted,
+				),
+			},
+			{
+				// Move the machines along their workflow; changing the triggers must restart it
+				// even though the machines are already set to the workflow.
+				PreConfig: func() {
+					mo, err := c.listModel(context.Background(), "machines", "Workflow", name)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, o := range mo {
+						var patcher jp.Patcher
+						patcher.Replace(jp.Ptr("/CurrentTask"), 0)
+						patch, err := patcher.Patch()
+						if err != nil {
+							t.Fatal(err)
+						}
+						if _, err := c.patchModel(context.Background(), "machines", o.Key(), patch); err != nil {
+							t.Fatal(err)
+						}
+					}
+				},
+				Config: config("2"),
+				Check:  checkRestarted,
+			},
+		},
+	})
+}
+
+func TestWorkflowRunStartWorkflow(t *testing.T) {
+	ctx := context.Background()
+	prior := uuid.NewRandom()
+	cases := []struct {
+		name      string
+		current   string
+		reset     bool
+		wantReset bool
+	}{
+		{name: "new workflow", current: "discover", wantReset: false},
+		{name: "new workflow with reset", current: "discover", reset: true, wantReset: true},
+		{name: "same workflow", current: "deploy", wantReset: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &testMachineServer{states: []*models.Machine{testMachine(tc.current, "failed", prior, true)}}
+			r := &workflowRunResource{client: testClient(t, srv.handler)}
+			job, err := r.startWorkflow(ctx, "3dc7b5d4-98a4-4c4d-9a31-3a4b8e0c6f11", "deploy", tc.reset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job != prior.String() {
+				t.Errorf("startWorkflow() job = %s, want %s", job, prior)
+			}
+			if len(srv.patches) != 1 {
+				t.Fatalf("sent %d patches, want 1", len(srv.patches))
+			}
+			if got := strings.Contains(srv.patches[0], "/CurrentTask"); got != tc.wantReset {
+				t.Errorf("patch %s resets CurrentTask = %v, want %v", srv.patches[0], got, tc.wantReset)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package drpv4
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
@@ -98,3 +99,25 @@ func TestAccWorkflowResource(t *testing.T) {
 		},
 	})
 }
+
+func TestAccWorkflowResourceValidateReferences(t *testing.T) {
+	name := fmt.Sprintf("tfwf_%s", accRandomSuffix(10))
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "drp" {
+						validate_references = true
+					}
+
+					resource "drp_workflow" "test" {
+						name = "%[1]s"
+						stages = ["%[1]s-missing"]
+					}`, name),
+				ExpectError: regexp.MustCompile(`stages/` + name + `-missing does not exist`),
+			},
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package drpv4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gitlab.com/rackn/jp"
+	"gitlab.com/rackn/provision/v4/api"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryMinWait = time.Second
+	defaultRetryMaxWait = 30 * time.Second
+)
+
+// retryPolicy controls how API calls that fail with a transient error are sent again.
+// The wait doubles from minWait after every attempt and is capped at maxWait.
+type retryPolicy struct {
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		maxRetries: defaultMaxRetries,
+		minWait:    defaultRetryMinWait,
+		maxWait:    defaultRetryMaxWait,
+	}
+}
+
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	wait := p.minWait
+	for i := 0; i < attempt && wait < p.maxWait; i++ {
+		wait *= 2
+	}
+	if wait > p.maxWait {
+		wait = p.maxWait
+	}
+	return wait
+}
+
+// retryable reports whether a request that failed with err can be sent again. Idempotent
+// methods are retried on transport errors, conflicts, throttling and server errors. POST
+// is only retried when the server cannot have acted on the request.
+func retryable(method string, err error) bool {
+	var e *models.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return e.Code == 0 || e.Code == http.StatusConflict || e.Code == http.StatusTooManyRequests || e.Code >= 500
+	case http.MethodPost:
+		if e.Code == 0 {
+			return strings.Contains(e.Error(), "connection refused")
+		}
+		return e.Code == http.StatusTooManyRequests || e.Code == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// do sends the request produced by build, retrying transient failures according to the
+// provider retry policy. build is called once per attempt since a request can only be
+// sent once.
+func (c *Config) do(ctx context.Context, build func() *api.R, val interface{}) error {
+	for attempt := 0; ; attempt++ {
+		r := build()
+		err := r.Do(val)
+		if err == nil || r.Req == nil {
+			return err
+		}
+		method, target := r.Req.Method, r.Req.URL.Path
+		// A DELETE whose response was lost has already removed the object.
+		var e *models.Error
+		if attempt > 0 && method == http.MethodDelete && errors.As(err, &e) && e.Code == http.StatusNotFound {
+			return nil
+		}
+		if attempt >= c.retry.maxRetries || !retryable(method, err) {
+			return err
+		}
+		wait := c.retry.backoff(attempt)
+		tflog.Warn(ctx, "Retrying DRP API request", map[string]interface{}{
+			"method":  method,
+			"path":    target,
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+			"error":   err.Error(),
+		})
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s %s: %w (gave up retrying: %s)", method, target, err, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Config) getModel(ctx context.Context, prefix, key string, params ...string) (models.Model, error) {
+	res, err := models.New(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return res, c.do(ctx, func() *api.R {
+		return c.session.Req().UrlFor(res.Prefix(), key).Params(params...)
+	}, res)
+}
+
+func (c *Config) listModel(ctx context.Context, prefix string, params ...string) ([]models.Model, error) {
+	ref, err := models.New(prefix)
+	if err != nil {
+		return nil, err
+	}
+	res := ref.SliceOf()
+	if err := c.do(ctx, func() *api.R {
+		return c.session.Req().UrlForM(ref).Params(params...)
+	}, &res); err != nil {
+		return nil, err
+	}
+	return ref.ToModels(res), nil
+}
+
+func (c *Config) createModel(ctx context.Context, ref models.Model) error {
+	return c.do(ctx, func() *api.R {
+		return c.session.Req().Post(ref).UrlFor(ref.Prefix())
+	}, &ref)
+}
+
+func (c *Config) putModel(ctx context.Context, obj models.Model) error {
+	return c.do(ctx, func() *api.R {
+		return c.session.Req().Put(obj).UrlForM(obj)
+	}, &obj)
+}
+
+func (c *Config) patchModel(ctx context.Context, prefix, key string, patch jp.Patch) (models.Model, error) {
+	item, err := models.New(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return item, c.do(ctx, func() *api.R {
+		return c.session.Req().Patch(patch).UrlFor(prefix, key)
+	}, &item)
+}
+
+func (c *Config) deleteModel(ctx context.Context, prefix, key string) (models.Model, error) {
+	res, err := models.New(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return res, c.do(ctx, func() *api.R {
+		return c.session.Req().Del().UrlFor(prefix, key)
+	}, &res)
+}
@@ -0,0 +1,175 @@
+package drpv4
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+// testClient returns a Config connected to an in-process server running h, with retries
+// that wait only a few milliseconds.
+func testClient(t *testing.T, h http.HandlerFunc) *Config {
+	t.Helper()
+	srv := httptest.NewTLSServer(h)
+	t.Cleanup(srv.Close)
+	c := &Config{token: "test", endpoint: srv.URL}
+	if err := c.validateAndConnect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c.retry = retryPolicy{maxRetries: 3, minWait: time.Millisecond, maxWait: 2 * time.Millisecond}
+	return c
+}
+
+func writeTestError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"Type":"API_ERROR","Code":%d,"Messages":[%q]}`, code, http.StatusText(code))
+}
+
+func TestRetryable(t *testing.T) {
+	methods := []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodPost}
+	idempotent := map[string]bool{
+		http.MethodGet: true, http.MethodHead: true, http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+	}
+	cases := []struct {
+		code       int
+		idempotent bool
+		post       bool
+	}{
+		{code: 0, idempotent: true, post: false},
+		{code: http.StatusBadRequest},
+		{code: http.StatusUnauthorized},
+		{code: http.StatusForbidden},
+		{code: http.StatusNotFound},
+		{code: http.StatusConflict, idempotent: true},
+		{code: http.StatusUnprocessableEntity},
+		{code: http.StatusTooManyRequests, idempotent: true, post: true},
+		{code: http.StatusInternalServerError, idempotent: true},
+		{code: http.StatusBadGateway, idempotent: true},
+		{code: http.StatusServiceUnavailable, idempotent: true, post: true},
+		{code: http.StatusGatewayTimeout, idempotent: true},
+	}
+	for _, tc := range cases {
+		for _, method := range methods {
+			err := &models.Error{Type: "API_ERROR", Code: tc.code, Messages: []string{"failed"}}
+			want := tc.post
+			if idempotent[method] {
+				want = tc.idempotent
+			}
+			if got := retryable(method, err); got != want {
+				t.Errorf("retryable(%s, %d) = %v, want %v", method, tc.code, got, want)
+			}
+		}
+	}
+
+	refused := &models.Error{Type: "CLIENT_ERROR", Messages: []string{"dial tcp: connection refused"}}
+	if !retryable(http.MethodPost, refused) {
+		t.Error("POST that was refused before reaching the server should be retried")
+	}
+	if retryable(http.MethodGet, errors.New("not an API error")) {
+		t.Error("errors that are not models.Error should not be retried")
+	}
+	if retryable(http.MethodOptions, &models.Error{Code: http.StatusInternalServerError}) {
+		t.Error("unknown methods should not be retried")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := retryPolicy{maxRetries: 10, minWait: time.Second, maxWait: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for attempt, w := range want {
+		if got := p.backoff(attempt); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, w)
+		}
+	}
+	if got := p.backoff(1000); got != p.maxWait {
+		t.Errorf("backoff(1000) = %s, want maxWait %s", got, p.maxWait)
+	}
+	if got := (retryPolicy{minWait: time.Minute, maxWait: time.Second}).backoff(0); got != time.Second {
+		t.Errorf("backoff with minWait above maxWait = %s, want %s", got, time.Second)
+	}
+}
+
+func TestConfigDoRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GET retried until it succeeds", func(t *testing.T) {
+		calls := 0
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				writeTestError(w, http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"Name":"p1"}`)
+		})
+		if _, err := c.getModel(ctx, "params", "p1"); err != nil {
+			t.Fatal(err)
+		}
+		if calls != 3 {
+			t.Errorf("sent %d requests, want 3", calls)
+		}
+	})
+
+	t.Run("GET gives up after maxRetries", func(t *testing.T) {
+		calls := 0
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			writeTestError(w, http.StatusBadGateway)
+		})
+		if _, err := c.getModel(ctx, "params", "p1"); err == nil {
+			t.Fatal("expected an error")
+		}
+		if calls != c.retry.maxRetries+1 {
+			t.Errorf("sent %d requests, want %d", calls, c.retry.maxRetries+1)
+		}
+	})
+
+	t.Run("POST not sent twice on a server error", func(t *testing.T) {
+		calls := 0
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			writeTestError(w, http.StatusInternalServerError)
+		})
+		if err := c.createModel(ctx, &models.Param{Name: "p1"}); err == nil {
+			t.Fatal("expected an error")
+		}
+		if calls != 1 {
+			t.Errorf("sent %d requests, want 1", calls)
+		}
+	})
+
+	t.Run("DELETE not found on retry is success", func(t *testing.T) {
+		calls := 0
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				writeTestError(w, http.StatusServiceUnavailable)
+				return
+			}
+			writeTestError(w, http.StatusNotFound)
+		})
+		if _, err := c.deleteModel(ctx, "params", "p1"); err != nil {
+			t.Fatalf("deleteModel() error = %v, want nil", err)
+		}
+		if calls != 2 {
+			t.Errorf("sent %d requests, want 2", calls)
+		}
+	})
+
+	t.Run("DELETE not found on the first attempt is an error", func(t *testing.T) {
+		c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeTestError(w, http.StatusNotFound)
+		})
+		if _, err := c.deleteModel(ctx, "params", "p1"); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package drpv4
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Templates may take their body from a local source_file instead of inline contents. Only
+// the SHA-256 of the body is kept in state for those, so plans show a hash change rather
+// than the whole script, and drift is detected by hashing what the server returns.
+
+// contentsSHA256 returns the hex SHA-256 of contents, or null for empty contents.
+func contentsSHA256(contents string) types.String {
+	if contents == "" {
+		return types.StringNull()
+	}
+	sum := sha256.Sum256([]byte(contents))
+	return types.StringValue(hex.EncodeToString(sum[:]))
+}
+
+func readTemplateSource(file string) (string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read source_file: %w", err)
+	}
+	return string(b), nil
+}
+
+// templateBody returns the template body from source_file when set, else the inline contents.
+func templateBody(contents, sourceFile types.String, attrPath path.Path, diags *diag.Diagnostics) string {
+	if sourceFile.IsNull() || sourceFile.IsUnknown() {
+		return contents.ValueString()
+	}
+	body, err := readTemplateSource(sourceFile.ValueString())
+	if err != nil {
+		diags.AddAttributeError(attrPath.AtName("source_file"), "Invalid source_file", err.Error())
+	}
+	return body
+}
+
+// planTemplateContents checks the syntax of the template body from contents or sourceFile and
+// returns the contents_sha256 that applying it yields.
+func planTemplateContents(contents, sourceFile types.String, startDelim, endDelim string, attrPath path.Path, diags *diag.Diagnostics) types.String {
+	if contents.IsUnknown() || sourceFile.IsUnknown() {
+		return types.StringUnknown()
+	}
+	body := templateBody(contents, sourceFile, attrPath, diags)
+	bodyPath := attrPath.AtName("contents")
+	if !sourceFile.IsNull() {
+		bodyPath = attrPath.AtName("source_file")
+	}
+	validateTemplateSyntax(body, startDelim, endDelim, bodyPath, diags)
+	return contentsSHA256(body)
+}
+
+// flattenTemplateContents keeps contents out of state when the body comes from source_file.
+func flattenTemplateContents(priorContents, priorSourceFile types.String, api string) types.String {
+	if !priorSourceFile.IsNull() {
+		return types.StringNull()
+	}
+	return mergeOptString(priorContents, api)
+}
+
+// templateSourceNestedAttributes are the source_file and contents_sha256 attributes shared by
+// the inline template blocks of tasks, stages and bootenvs.
+func templateSourceNestedAttributes(attrs map[string]schema.Attribute) map[string]schema.Attribute {
+	attrs["source_file"] = schema.StringAttribute{
+		Optional:    true,
+		Description: "Local file to read the template contents from, instead of contents.",
+		Validators: []validator.String{
+			stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("contents")),
+		},
+	}
+	attrs["contents_sha256"] = schema.StringAttribute{
+		Computed:    true,
+		Description: "SHA-256 of the template contents.",
+	}
+	return attrs
+}
+
+// planTemplateHashes fills in contents_sha256 of every element of a planned templates list
+// and checks the syntax of each template body.
+func planTemplateHashes(ctx context.Context, l types.List, attrPath path.Path, diags *diag.Diagnostics) types.List {
+	if l.IsNull() || l.IsUnknown() {
+		return l
+	}
+	objType, ok := l.ElementType(ctx).(types.ObjectType)
+	if !ok {
+		return l
+	}
+	elems := make([]attr.Value, 0, len(l.Elements()))
+	for i, el := range l.Elements() {
+		o, ok := el.(types.Object)
+		if !ok || o.IsNull() || o.IsUnknown() {
+			elems = append(elems, el)
+			continue
+		}
+		attrs := make(map[string]attr.Value, len(o.Attributes()))
+		for k, v := range o.Attributes() {
+			attrs[k] = v
+		}
+		attrs["contents_sha256"] = planTemplateContents(
+			priorObjString(o, "contents"), priorObjString(o, "source_file"), "", "", attrPath.AtListIndex(i), diags)
+		obj, d := types.ObjectValue(objType.AttrTypes, attrs)
+		diags.Append(d...)
+		elems = append(elems, obj)
+	}
+	out, d := types.ListValue(objType, elems)
+	diags.Append(d...)
+	return out
+}
+
+// modifyPlanTemplateHashes plans contents_sha256 for the templates list attribute at attrPath
+// and reports template syntax errors.
+func modifyPlanTemplateHashes(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse, attrPath path.Path) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var l types.List
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, attrPath, &l)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	l = planTemplateHashes(ctx, l, attrPath, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, attrPath, l)...)
+}
@@ -0,0 +1,51 @@
+package drpv4
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"gitlab.com/rackn/provision/v4/models"
+)
+
+const syntaxCheckTemplateName = "contents"
+
+// validateTemplateSyntax parses body the way DRP does when it renders a template, so syntax
+// errors and unknown functions are caught at plan time instead of when a machine runs the
+// task. Empty delimiters mean the text/template defaults.
+func validateTemplateSyntax(body, startDelim, endDelim string, attrPath path.Path, diags *diag.Diagnostics) {
+	if body == "" {
+		return
+	}
+	_, err := template.New(syntaxCheckTemplateName).
+		Funcs(models.DrpSafeFuncMap()).
+		Delims(startDelim, endDelim).
+		Parse(body)
+	if err == nil {
+		return
+	}
+	line, msg := templateErrorLine(err)
+	detail := msg
+	if line > 0 {
+		detail = fmt.Sprintf("line %d: %s", line, msg)
+	}
+	diags.AddAttributeError(attrPath, "Invalid template syntax", detail)
+}
+
+// templateErrorLine splits a text/template parse error of the form
+// "template: contents:12: unexpected ..." into its line number and message.
+func templateErrorLine(err error) (int, string) {
+	msg := strings.TrimPrefix(err.Error(), "template: "+syntaxCheckTemplateName+":")
+	lineStr, rest, ok := strings.Cut(msg, ":")
+	if !ok {
+		return 0, err.Error()
+	}
+	line, convErr := strconv.Atoi(lineStr)
+	if convErr != nil {
+		return 0, err.Error()
+	}
+	return line, strings.TrimSpace(rest)
+}